@@ -10,6 +10,27 @@ type SetPasswordBySessionCallRequest struct {
 	SessionDurationMinutes int32  `json:"session_duration_minutes"`
 }
 
+type PasswordStrengthCallRequest struct {
+	Email    string `json:"email" validate:"omitempty,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type StartPasswordResetCallRequest struct {
+	Email         string `json:"email" validate:"required,email"`
+	CodeChallenge string `json:"code_challenge" validate:"required"`
+}
+
+type StartPasswordResetCallResponse struct {
+	Message string `json:"message"`
+}
+
+type CompletePasswordResetCallRequest struct {
+	Token                  string `json:"token" validate:"required"`
+	Password               string `json:"password" validate:"required"`
+	CodeVerifier           string `json:"code_verifier" validate:"required"`
+	SessionDurationMinutes int32  `json:"session_duration_minutes" validate:"omitempty,min=5,max=527040"`
+}
+
 type AuthenticateMagicLinkCallRequest struct {
 	Token        string `json:"token"`
 	CodeVerifier string `json:"code_verifier"`
@@ -32,3 +53,27 @@ type AuthenticateOAuthCallRequest struct {
 type ExtendSessionCallRequest struct {
 	SessionDurationMinutes int32 `json:"session_duration_minutes"`
 }
+
+type SendEmailOTPCallRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type SendSMSOTPCallRequest struct {
+	PhoneNumber string `json:"phone_number" validate:"required,e164"`
+}
+
+type AuthenticateOTPCallRequest struct {
+	MethodID               string `json:"method_id" validate:"required"`
+	Code                   string `json:"code" validate:"required,len=6,numeric"`
+	SessionDurationMinutes int32  `json:"session_duration_minutes" validate:"omitempty,min=5,max=527040"`
+}
+
+type AuthenticateTOTPCallRequest struct {
+	Code                   string `json:"code" validate:"required,len=6,numeric"`
+	SessionDurationMinutes int32  `json:"session_duration_minutes" validate:"omitempty,min=5,max=527040"`
+}
+
+type RecoverTOTPCallRequest struct {
+	RecoveryCode           string `json:"recovery_code" validate:"required"`
+	SessionDurationMinutes int32  `json:"session_duration_minutes" validate:"omitempty,min=5,max=527040"`
+}
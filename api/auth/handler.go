@@ -3,8 +3,10 @@ package auth
 import (
 	"driftGo/api/common/errors"
 	"driftGo/api/common/validation"
+	"driftGo/api/middleware"
 	"driftGo/domain/auth"
 	"encoding/json"
+	stderrors "errors"
 	"net/http"
 
 	log "github.com/sirupsen/logrus"
@@ -30,7 +32,23 @@ func SetupRoutes(r chi.Router, service *auth.Service) {
 		r.Post("/OAuth", handler.authenticateOAuthCall)
 		r.Post("/magiclink", handler.authenticateMagicLinkCall)
 	})
+	r.Route("/otp", func(r chi.Router) {
+		r.Post("/email/send", handler.sendEmailOTPCall)
+		r.Post("/sms/send", handler.sendSMSOTPCall)
+		r.Post("/authenticate", handler.authenticateOTPCall)
+	})
+	r.Route("/totp", func(r chi.Router) {
+		r.With(middleware.RequireStepUp).Post("/", handler.createTOTPCall)
+		r.Post("/authenticate", handler.authenticateTOTPCall)
+		r.Post("/recover", handler.recoverTOTPCall)
+		r.With(middleware.RequireSecondFactor).Get("/recoveryCodes", handler.getTOTPRecoveryCodesCall)
+	})
 	r.Post("/setPassword", handler.setPasswordCall)
+	r.Route("/password", func(r chi.Router) {
+		r.Post("/strength", handler.passwordStrengthCall)
+		r.Post("/reset/start", handler.startPasswordResetCall)
+		r.Post("/reset/complete", handler.completePasswordResetCall)
+	})
 	r.Post("/login", handler.loginCall)
 	r.Post("/logout", handler.logoutCall)
 	r.Post("/attachOAuth", handler.attachOAuthCall)
@@ -76,6 +94,203 @@ func (h *Handler) sendCreateAccountMagicLinkCall(w http.ResponseWriter, r *http.
 	}
 }
 
+/*
+sendEmailOTPCall handles the request to email a one-time passcode.
+This starts the code based login flow and creates the user if the email is new.
+The request body should contain the email.
+*/
+func (h *Handler) sendEmailOTPCall(w http.ResponseWriter, r *http.Request) {
+	var sendEmailOTPCallRequest SendEmailOTPCallRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&sendEmailOTPCallRequest); err != nil {
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, sendEmailOTPCallRequest) {
+		return
+	}
+
+	resp, err := h.service.SendEmailOTP(r.Context(), sendEmailOTPCallRequest.Email)
+	if err != nil {
+		log.WithError(err).Error("Failed to send email OTP")
+		errors.InternalErrorHandler(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+sendSMSOTPCall handles the request to text a one-time passcode.
+This starts the code based login flow and creates the user if the phone number is new.
+The request body should contain the phone number in E.164 format.
+*/
+func (h *Handler) sendSMSOTPCall(w http.ResponseWriter, r *http.Request) {
+	var sendSMSOTPCallRequest SendSMSOTPCallRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&sendSMSOTPCallRequest); err != nil {
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, sendSMSOTPCallRequest) {
+		return
+	}
+
+	resp, err := h.service.SendSMSOTP(r.Context(), sendSMSOTPCallRequest.PhoneNumber)
+	if err != nil {
+		log.WithError(err).Error("Failed to send SMS OTP")
+		errors.InternalErrorHandler(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+authenticateOTPCall handles the request to authenticate a one-time passcode.
+This completes the code based login flow and returns a new session.
+The request body should contain the method ID returned when the code was sent and the code.
+*/
+func (h *Handler) authenticateOTPCall(w http.ResponseWriter, r *http.Request) {
+	var authenticateOTPCallRequest AuthenticateOTPCallRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&authenticateOTPCallRequest); err != nil {
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, authenticateOTPCallRequest) {
+		return
+	}
+
+	resp, err := h.service.AuthenticateOTP(r.Context(), authenticateOTPCallRequest.MethodID, authenticateOTPCallRequest.Code, authenticateOTPCallRequest.SessionDurationMinutes)
+	if err != nil {
+		log.WithError(err).Error("Failed to authenticate OTP")
+		errors.RequestErrorHandler(w, errors.NewErrorWithCode(http.StatusUnauthorized, "Invalid or expired passcode", errors.ErrCodeAuthentication))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+createTOTPCall handles the request to enroll an authenticator app.
+The route is wrapped in RequireStepUp, and a user with a verified authenticator must also have used it recently.
+The response carries the secret, QR code and recovery codes, which are only shown once.
+*/
+func (h *Handler) createTOTPCall(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.CreateTOTP(r.Context())
+	if err != nil {
+		if stderrors.Is(err, auth.ErrSecondFactorRequired) {
+			errors.StepUpRequiredErrorHandler(w, errors.MsgSecondFactorRequired)
+			return
+		}
+		log.WithError(err).Error("Failed to create TOTP")
+		errors.InternalErrorHandler(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+authenticateTOTPCall handles the request to authenticate an authenticator app code.
+It completes enrollment the first time and adds a second factor to the session after that.
+The request body should contain the code.
+*/
+func (h *Handler) authenticateTOTPCall(w http.ResponseWriter, r *http.Request) {
+	var authenticateTOTPCallRequest AuthenticateTOTPCallRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&authenticateTOTPCallRequest); err != nil {
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, authenticateTOTPCallRequest) {
+		return
+	}
+
+	resp, err := h.service.AuthenticateTOTP(r.Context(), authenticateTOTPCallRequest.Code, authenticateTOTPCallRequest.SessionDurationMinutes)
+	if err != nil {
+		log.WithError(err).Error("Failed to authenticate TOTP")
+		errors.RequestErrorHandler(w, errors.NewErrorWithCode(http.StatusUnauthorized, "Invalid authenticator code", errors.ErrCodeAuthentication))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+recoverTOTPCall handles the request to use a recovery code instead of an authenticator app code.
+The request body should contain the recovery code.
+*/
+func (h *Handler) recoverTOTPCall(w http.ResponseWriter, r *http.Request) {
+	var recoverTOTPCallRequest RecoverTOTPCallRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&recoverTOTPCallRequest); err != nil {
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, recoverTOTPCallRequest) {
+		return
+	}
+
+	resp, err := h.service.RecoverTOTP(r.Context(), recoverTOTPCallRequest.RecoveryCode, recoverTOTPCallRequest.SessionDurationMinutes)
+	if err != nil {
+		log.WithError(err).Error("Failed to recover TOTP")
+		errors.RequestErrorHandler(w, errors.NewErrorWithCode(http.StatusUnauthorized, "Invalid recovery code", errors.ErrCodeAuthentication))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+getTOTPRecoveryCodesCall handles the request to list the unused recovery codes.
+The route is wrapped in RequireSecondFactor, so a password alone cannot reveal them.
+*/
+func (h *Handler) getTOTPRecoveryCodesCall(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.GetTOTPRecoveryCodes(r.Context())
+	if err != nil {
+		log.WithError(err).Error("Failed to get TOTP recovery codes")
+		errors.InternalErrorHandler(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
 /*
 setPasswordCall handles the request to set a password for a user.
 This is used in the password set flow.
@@ -95,6 +310,11 @@ func (h *Handler) setPasswordCall(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.service.SetPasswordBySession(r.Context(), setPasswordBySessionCallRequest.Password, setPasswordBySessionCallRequest.SessionDurationMinutes)
 	if err != nil {
+		var weakPassword *auth.WeakPasswordError
+		if stderrors.As(err, &weakPassword) {
+			errors.WeakPasswordErrorHandler(w, weakPassword.Feedback.Breached, weakPassword.Feedback)
+			return
+		}
 		log.WithError(err).Error("Failed to set password")
 		errors.RequestErrorHandler(w, errors.NewErrorWithCode(http.StatusBadRequest, "Failed to set password. Please try again.", errors.ErrCodeAuthentication))
 		return
@@ -107,6 +327,110 @@ func (h *Handler) setPasswordCall(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+/*
+passwordStrengthCall handles the request to rate a password before it is set.
+The response carries the zxcvbn or LUDS feedback and whether the password has been breached.
+The request body should contain the password and optionally the email it will belong to.
+*/
+func (h *Handler) passwordStrengthCall(w http.ResponseWriter, r *http.Request) {
+	var passwordStrengthCallRequest PasswordStrengthCallRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&passwordStrengthCallRequest); err != nil {
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, passwordStrengthCallRequest) {
+		return
+	}
+
+	resp, err := h.service.CheckPasswordStrength(r.Context(), passwordStrengthCallRequest.Email, passwordStrengthCallRequest.Password)
+	if err != nil {
+		log.WithError(err).Error("Failed to check password strength")
+		errors.InternalErrorHandler(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+startPasswordResetCall handles the request to email a password reset link.
+This is the entry point of the forgotten password flow.
+The request body should contain the email and code challenge.
+The response is the same whether or not the email has an account.
+*/
+func (h *Handler) startPasswordResetCall(w http.ResponseWriter, r *http.Request) {
+	var startPasswordResetCallRequest StartPasswordResetCallRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&startPasswordResetCallRequest); err != nil {
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, startPasswordResetCallRequest) {
+		return
+	}
+
+	if err := h.service.StartPasswordReset(r.Context(), startPasswordResetCallRequest.Email, startPasswordResetCallRequest.CodeChallenge); err != nil {
+		log.WithError(err).Error("Failed to start password reset")
+		errors.InternalErrorHandler(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(StartPasswordResetCallResponse{Message: "If an account exists for this email, a password reset link has been sent."}); err != nil {
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+completePasswordResetCall handles the request to set a new password from a reset link.
+This completes the forgotten password flow and returns a new session.
+The request body should contain the token, new password and code verifier.
+*/
+func (h *Handler) completePasswordResetCall(w http.ResponseWriter, r *http.Request) {
+	var completePasswordResetCallRequest CompletePasswordResetCallRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&completePasswordResetCallRequest); err != nil {
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, completePasswordResetCallRequest) {
+		return
+	}
+
+	resp, err := h.service.ResetPassword(
+		r.Context(),
+		completePasswordResetCallRequest.Token,
+		completePasswordResetCallRequest.Password,
+		completePasswordResetCallRequest.CodeVerifier,
+		completePasswordResetCallRequest.SessionDurationMinutes,
+	)
+	if err != nil {
+		var weakPassword *auth.WeakPasswordError
+		if stderrors.As(err, &weakPassword) {
+			errors.WeakPasswordErrorHandler(w, weakPassword.Feedback.Breached, weakPassword.Feedback)
+			return
+		}
+		log.WithError(err).Error("Failed to reset password")
+		errors.RequestErrorHandler(w, errors.NewErrorWithCode(http.StatusBadRequest, "Failed to reset password. The link may have expired.", errors.ErrCodeAuthentication))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
 /*
 authenticateMagicLinkCall handles the request to authenticate a magic link.
 This is used in the magic link login flow.
@@ -237,6 +561,10 @@ func (h *Handler) attachOAuthCall(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.service.AttachOAuth(r.Context(), attachOAuthCallRequest.UserId, attachOAuthCallRequest.Provider)
 	if err != nil {
+		if stderrors.Is(err, auth.ErrForbidden) {
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+			return
+		}
 		log.WithError(err).Error("Failed to attach OAuth")
 		errors.InternalErrorHandler(w)
 		return
@@ -11,9 +11,10 @@ import (
 )
 
 type Error struct {
-	StatusCode int    `json:"status_code"`
-	Message    string `json:"message"`
-	Code       string `json:"code,omitempty"`
+	StatusCode int                    `json:"status_code"`
+	Message    string                 `json:"message"`
+	Code       string                 `json:"code,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
 }
 
 func NewErrorWithCode(statusCode int, message, code string) *Error {
@@ -24,30 +25,56 @@ func NewErrorWithCode(statusCode int, message, code string) *Error {
 	}
 }
 
+/*
+NewErrorWithDetails creates an error that carries structured details clients can act on
+*/
+func NewErrorWithDetails(statusCode int, message, code string, details map[string]interface{}) *Error {
+	return &Error{
+		StatusCode: statusCode,
+		Message:    message,
+		Code:       code,
+		Details:    details,
+	}
+}
+
 func (e *Error) Error() string {
 	return fmt.Sprintf("%s (status: %d)", e.Message, e.StatusCode)
 }
 
 const (
-	ErrCodeInvalidRequest  = "INVALID_REQUEST"
-	ErrCodeUnauthorized    = "UNAUTHORIZED"
-	ErrCodeForbidden       = "FORBIDDEN"
-	ErrCodeNotFound        = "NOT_FOUND"
-	ErrCodeInternalError   = "INTERNAL_ERROR"
-	ErrCodeValidationError = "VALIDATION_ERROR"
-	ErrCodeAuthentication  = "AUTHENTICATION_ERROR"
-	ErrCodeInvalidFormat   = "INVALID_FORMAT"
+	ErrCodeInvalidRequest   = "INVALID_REQUEST"
+	ErrCodeUnauthorized     = "UNAUTHORIZED"
+	ErrCodeForbidden        = "FORBIDDEN"
+	ErrCodeNotFound         = "NOT_FOUND"
+	ErrCodeConflict         = "CONFLICT"
+	ErrCodeInternalError    = "INTERNAL_ERROR"
+	ErrCodeValidationError  = "VALIDATION_ERROR"
+	ErrCodeAuthentication   = "AUTHENTICATION_ERROR"
+	ErrCodeInvalidFormat    = "INVALID_FORMAT"
+	ErrCodeDuplicateItem    = "DUPLICATE_ITEM"
+	ErrCodeStepUpRequired   = "STEP_UP_REQUIRED"
+	ErrCodeTransferDeclined = "TRANSFER_DECLINED"
+	ErrCodeDebitDeclined    = "DEBIT_DECLINED"
+	ErrCodeWeakPassword     = "WEAK_PASSWORD"
 )
 
 const (
-	MsgInvalidRequest  = "The request was invalid or malformed!"
-	MsgUnauthorized    = "You are not authorized to perform this action!"
-	MsgForbidden       = "You don't have permission to access this resource!"
-	MsgNotFound        = "The requested resource was not found!"
-	MsgInternalError   = "An unexpected error occurred. Please try again later!"
-	MsgValidationError = "The request failed validation!"
-	MsgAuthentication  = "Authentication failed!"
-	MsgInvalidFormat   = "Invalid request format!"
+	MsgInvalidRequest       = "The request was invalid or malformed!"
+	MsgUnauthorized         = "You are not authorized to perform this action!"
+	MsgForbidden            = "You don't have permission to access this resource!"
+	MsgNotFound             = "The requested resource was not found!"
+	MsgConflict             = "The request conflicts with the current state of the resource!"
+	MsgInternalError        = "An unexpected error occurred. Please try again later!"
+	MsgValidationError      = "The request failed validation!"
+	MsgAuthentication       = "Authentication failed!"
+	MsgInvalidFormat        = "Invalid request format!"
+	MsgDuplicateItem        = "These accounts are already linked!"
+	MsgStepUpRequired       = "Please authenticate again to continue!"
+	MsgSecondFactorRequired = "Please enter a code from your authenticator app to continue!"
+	MsgTransferDeclined     = "The transfer was not authorized!"
+	MsgDebitDeclined        = "This account cannot be debited right now!"
+	MsgWeakPassword         = "This password is too weak!"
+	MsgBreachedPassword     = "This password has appeared in a data breach!"
 )
 
 func writeError(w http.ResponseWriter, err *Error) {
@@ -86,6 +113,45 @@ var (
 		writeError(w, NewErrorWithCode(http.StatusForbidden, message, ErrCodeForbidden))
 	}
 
+	ConflictErrorHandler = func(w http.ResponseWriter, message string) {
+		writeError(w, NewErrorWithCode(http.StatusConflict, message, ErrCodeConflict))
+	}
+
+	DuplicateItemErrorHandler = func(w http.ResponseWriter, existingItemID int64) {
+		writeError(w, NewErrorWithDetails(http.StatusConflict, MsgDuplicateItem, ErrCodeDuplicateItem, map[string]interface{}{
+			"existing_item_id": existingItemID,
+		}))
+	}
+
+	TransferDeclinedErrorHandler = func(w http.ResponseWriter, decision, code, description string) {
+		writeError(w, NewErrorWithDetails(http.StatusUnprocessableEntity, MsgTransferDeclined, ErrCodeTransferDeclined, map[string]interface{}{
+			"decision":    decision,
+			"code":        code,
+			"description": description,
+		}))
+	}
+
+	DebitDeclinedErrorHandler = func(w http.ResponseWriter, reason string, checkID int64) {
+		writeError(w, NewErrorWithDetails(http.StatusUnprocessableEntity, MsgDebitDeclined, ErrCodeDebitDeclined, map[string]interface{}{
+			"reason":   reason,
+			"check_id": checkID,
+		}))
+	}
+
+	WeakPasswordErrorHandler = func(w http.ResponseWriter, breached bool, feedback interface{}) {
+		message := MsgWeakPassword
+		if breached {
+			message = MsgBreachedPassword
+		}
+		writeError(w, NewErrorWithDetails(http.StatusBadRequest, message, ErrCodeWeakPassword, map[string]interface{}{
+			"feedback": feedback,
+		}))
+	}
+
+	StepUpRequiredErrorHandler = func(w http.ResponseWriter, message string) {
+		writeError(w, NewErrorWithCode(http.StatusForbidden, message, ErrCodeStepUpRequired))
+	}
+
 	ValidationErrorHandler = func(w http.ResponseWriter, message string) {
 		writeError(w, NewErrorWithCode(http.StatusBadRequest, message, ErrCodeValidationError))
 	}
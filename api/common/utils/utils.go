@@ -2,12 +2,20 @@ package utils
 
 import (
 	"context"
+	"time"
 )
 
 type AuthContext struct {
 	UserID       int64
 	StytchUserID string
 	SessionToken string
+	SessionJWT   string
+
+	// Most recent time any factor of the session was authenticated
+	AuthenticatedAt time.Time
+
+	// Most recent time a second factor of the session was authenticated, zero if it has none
+	SecondFactorAt time.Time
 }
 
 type ctxKey string
@@ -30,6 +38,13 @@ func GetSessionToken(ctx context.Context) string {
 	return ""
 }
 
+func GetSessionJWT(ctx context.Context) string {
+	if auth, ok := ctx.Value(authCtxKey).(AuthContext); ok {
+		return auth.SessionJWT
+	}
+	return ""
+}
+
 func GetUserID(ctx context.Context) int64 {
 	if auth, ok := ctx.Value(authCtxKey).(AuthContext); ok {
 		return auth.UserID
@@ -43,3 +58,17 @@ func GetStytchUserID(ctx context.Context) string {
 	}
 	return ""
 }
+
+func GetAuthenticatedAt(ctx context.Context) time.Time {
+	if auth, ok := ctx.Value(authCtxKey).(AuthContext); ok {
+		return auth.AuthenticatedAt
+	}
+	return time.Time{}
+}
+
+func GetSecondFactorAt(ctx context.Context) time.Time {
+	if auth, ok := ctx.Value(authCtxKey).(AuthContext); ok {
+		return auth.SecondFactorAt
+	}
+	return time.Time{}
+}
@@ -1,12 +1,15 @@
 package api
 
 import (
+	"context"
 	"driftGo/api/webhook"
 	"driftGo/config"
 	"driftGo/db"
 	authDomain "driftGo/domain/auth"
 	linkDomain "driftGo/domain/link"
+	paymentDomain "driftGo/domain/payment"
 	userDomain "driftGo/domain/user"
+	"driftGo/pkg/stripe"
 )
 
 /*
@@ -15,6 +18,7 @@ Services holds all the service instances
 type Services struct {
 	Auth    *authDomain.Service
 	Link    *linkDomain.Service
+	Payment *paymentDomain.Service
 	User    *userDomain.Service
 	Webhook *webhook.WebhookHandler
 }
@@ -30,16 +34,43 @@ func InitializeServices() (*Services, error) {
 	userService := userDomain.NewService(pool)
 
 	// Initialize Auth Service
-	authService, err := authDomain.NewService(config.ProjectID, config.Secret)
+	authService, err := authDomain.NewService(config.ProjectID, config.Secret, config.StytchSessionJWTMaxAge)
 	if err != nil {
 		return nil, err
 	}
 
+	// Stripe is optional, bank account attachment is disabled without a secret
+	var stripeClient *stripe.Client
+	if config.StripeSecret != "" {
+		stripeClient = stripe.NewClient(config.StripeSecret, config.StripeBaseURL)
+	}
+
 	// Initialize Link Service
 	linkService, err := linkDomain.NewService(
 		config.PlaidClientID,
 		config.PlaidSecret,
 		config.PlaidEnv,
+		linkDomain.LinkTokenConfig{
+			ClientName:                  config.PlaidClientName,
+			Language:                    config.PlaidLanguage,
+			CountryCodes:                config.PlaidCountryCodes,
+			Products:                    config.PlaidProducts,
+			OptionalProducts:            config.PlaidOptionalProducts,
+			RequiredIfSupportedProducts: config.PlaidRequiredIfSupportedProducts,
+			WebhookURL:                  config.PlaidWebhookURL,
+			RedirectURI:                 config.PlaidRedirectURI,
+			AllowedProducts:             config.PlaidAllowedProducts,
+			AllowedWebhookURLs:          config.PlaidAllowedWebhookURLs,
+			AllowedRedirectURIs:         config.PlaidAllowedRedirectURIs,
+			AllowedAccountTypes:         config.PlaidAllowedAccountTypes,
+		},
+		config.PlaidProcessors,
+		linkDomain.DebitGuardConfig{
+			MinRemainingBalance:     config.DebitMinRemainingBalance,
+			MaxAmount:               config.DebitMaxAmount,
+			RequireAvailableBalance: config.DebitRequireAvailableBalance,
+		},
+		stripeClient,
 		userService,
 		pool,
 		config.EncryptionKey,
@@ -48,12 +79,21 @@ func InitializeServices() (*Services, error) {
 		return nil, err
 	}
 
+	// Compute blind indexes for rows stored before they existed
+	if err := linkService.BackfillBlindIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+
+	// Initialize Payment Service
+	paymentService := paymentDomain.NewService(pool, linkService, stripeClient)
+
 	// Initialize Webhook Handler
-	webhookHandler := webhook.NewWebhookHandler(userService, config.WebhookSecret)
+	webhookHandler := webhook.NewWebhookHandler(userService, linkService, paymentService, config.WebhookSecret, config.StripeWebhookSecret)
 
 	return &Services{
 		Auth:    authService,
 		Link:    linkService,
+		Payment: paymentService,
 		User:    userService,
 		Webhook: webhookHandler,
 	}, nil
@@ -1,9 +1,368 @@
 package link
 
+import (
+	"driftGo/domain/link"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const dateLayout = "2006-01-02"
+
+type CreateLinkTokenCallRequest struct {
+	Products                    []string            `json:"products" validate:"omitempty,dive,required"`
+	OptionalProducts            []string            `json:"optional_products" validate:"omitempty,dive,required"`
+	RequiredIfSupportedProducts []string            `json:"required_if_supported_products" validate:"omitempty,dive,required"`
+	AccountFilters              map[string][]string `json:"account_filters" validate:"omitempty,dive,dive,required"`
+	Webhook                     string              `json:"webhook" validate:"omitempty,url"`
+	RedirectURI                 string              `json:"redirect_uri" validate:"omitempty,url"`
+}
+
 type ExchangePublicTokenCallRequest struct {
 	PublicToken string `json:"public_token" validate:"required"`
 }
 
 type CreateStripeProcessorTokenCallRequest struct {
-	AccountID string `json:"account_id" validate:"required"`
+	AccountID        string `json:"account_id" validate:"required"`
+	AttachToCustomer bool   `json:"attach_to_customer"`
+}
+
+type CreateProcessorTokenCallRequest struct {
+	AccountID int64  `json:"account_id" validate:"required"`
+	Processor string `json:"processor" validate:"required"`
+}
+
+type SetProcessorTokenPermissionsCallRequest struct {
+	Products []string `json:"products" validate:"required,min=1"`
+}
+
+type CreateTransferCallRequest struct {
+	AccountID   int64  `json:"account_id" validate:"required"`
+	Type        string `json:"type" validate:"required,oneof=debit credit"`
+	Amount      int64  `json:"amount" validate:"required,min=1"`
+	Description string `json:"description" validate:"required,max=15"`
+}
+
+type GetTransfersCallRequest struct {
+	Limit  int32 `schema:"limit" validate:"omitempty,min=1,max=500"`
+	Offset int32 `schema:"offset" validate:"omitempty,min=0"`
+}
+
+type CreateUpdateLinkTokenCallRequest struct {
+	AccountSelectionEnabled bool `json:"account_selection_enabled"`
+}
+
+type GetTransactionsCallRequest struct {
+	Limit  int32 `schema:"limit" validate:"omitempty,min=1,max=500"`
+	Offset int32 `schema:"offset" validate:"omitempty,min=0"`
+}
+
+type GetBalancesCallRequest struct {
+	From string `schema:"from" validate:"omitempty,datetime=2006-01-02"`
+	To   string `schema:"to" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type RefreshBalanceCallRequest struct {
+	Realtime bool `json:"realtime"`
+}
+
+type TransactionCallResponse struct {
+	ID                   int64   `json:"id"`
+	TransactionID        string  `json:"transaction_id"`
+	AccountID            int64   `json:"account_id"`
+	Amount               float64 `json:"amount"`
+	IsoCurrencyCode      string  `json:"iso_currency_code,omitempty"`
+	Date                 string  `json:"date"`
+	AuthorizedDate       string  `json:"authorized_date,omitempty"`
+	Name                 string  `json:"name"`
+	MerchantName         string  `json:"merchant_name,omitempty"`
+	PaymentChannel       string  `json:"payment_channel,omitempty"`
+	Category             string  `json:"category,omitempty"`
+	CategoryDetailed     string  `json:"category_detailed,omitempty"`
+	Pending              bool    `json:"pending"`
+	PendingTransactionID string  `json:"pending_transaction_id,omitempty"`
+}
+
+type BalanceCallResponse struct {
+	ID                     int64     `json:"id"`
+	AccountID              int64     `json:"account_id"`
+	Current                *float64  `json:"current"`
+	Available              *float64  `json:"available"`
+	Limit                  *float64  `json:"limit"`
+	IsoCurrencyCode        string    `json:"iso_currency_code,omitempty"`
+	UnofficialCurrencyCode string    `json:"unofficial_currency_code,omitempty"`
+	Source                 string    `json:"source"`
+	CapturedAt             time.Time `json:"captured_at"`
+}
+
+type ItemCallResponse struct {
+	ID                    int64      `json:"id"`
+	InstitutionID         string     `json:"institution_id,omitempty"`
+	InstitutionName       string     `json:"institution_name,omitempty"`
+	Status                string     `json:"status"`
+	ErrorCode             string     `json:"error_code,omitempty"`
+	ErrorMessage          string     `json:"error_message,omitempty"`
+	ConsentExpirationTime *time.Time `json:"consent_expiration_time,omitempty"`
+	LastSuccessfulUpdate  *time.Time `json:"last_successful_update,omitempty"`
+	LastFailedUpdate      *time.Time `json:"last_failed_update,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+}
+
+type AccountCallResponse struct {
+	ID                 int64      `json:"id"`
+	ItemID             int64      `json:"item_id"`
+	Name               string     `json:"name"`
+	OfficialName       string     `json:"official_name,omitempty"`
+	Mask               string     `json:"mask,omitempty"`
+	Type               string     `json:"type,omitempty"`
+	Subtype            string     `json:"subtype,omitempty"`
+	ClosedAt           *time.Time `json:"closed_at,omitempty"`
+	IdentityMatchScore *int32     `json:"identity_match_score,omitempty"`
+	IdentityNameScore  *int32     `json:"identity_name_score,omitempty"`
+	IdentityEmailScore *int32     `json:"identity_email_score,omitempty"`
+	IdentityUpdatedAt  *time.Time `json:"identity_updated_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+type AccountNumbersCallResponse struct {
+	AccountID         int64      `json:"account_id"`
+	Network           string     `json:"network"`
+	AccountNumber     string     `json:"account_number"`
+	RoutingNumber     string     `json:"routing_number"`
+	WireRoutingNumber string     `json:"wire_routing_number,omitempty"`
+	BranchNumber      string     `json:"branch_number,omitempty"`
+	IsTokenized       bool       `json:"is_tokenized"`
+	Masked            bool       `json:"masked"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"`
+}
+
+type StripeProcessorTokenCallResponse struct {
+	AccountID              int64  `json:"account_id"`
+	StripeBankAccountToken string `json:"stripe_bank_account_token"`
+	StripeCustomerID       string `json:"stripe_customer_id,omitempty"`
+	StripeBankAccountID    string `json:"stripe_bank_account_id,omitempty"`
+}
+
+type ProcessorTokenCallResponse struct {
+	ID             int64      `json:"id"`
+	ItemID         *int64     `json:"item_id,omitempty"`
+	AccountID      *int64     `json:"account_id,omitempty"`
+	Processor      string     `json:"processor"`
+	ProcessorToken string     `json:"processor_token,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
+}
+
+type UnlinkProcessorTokenItemCallResponse struct {
+	ItemID                   int64   `json:"item_id"`
+	RevokedProcessorTokenIDs []int64 `json:"revoked_processor_token_ids"`
+}
+
+type TransferCallResponse struct {
+	ID                 int64      `json:"id"`
+	AccountID          *int64     `json:"account_id,omitempty"`
+	TransferID         string     `json:"transfer_id,omitempty"`
+	Type               string     `json:"type"`
+	Amount             *float64   `json:"amount"`
+	Description        string     `json:"description"`
+	Status             string     `json:"status"`
+	FailureCode        string     `json:"failure_code,omitempty"`
+	AchReturnCode      string     `json:"ach_return_code,omitempty"`
+	FailureDescription string     `json:"failure_description,omitempty"`
+	CreatedAt          *time.Time `json:"created_at,omitempty"`
+	UpdatedAt          *time.Time `json:"updated_at,omitempty"`
+}
+
+type IdentityOwnerCallResponse struct {
+	Names        []string `json:"names"`
+	Emails       []string `json:"emails"`
+	PhoneNumbers []string `json:"phone_numbers"`
+	Addresses    []string `json:"addresses"`
+}
+
+type IdentityCallResponse struct {
+	AccountID  int64                       `json:"account_id"`
+	Owners     []IdentityOwnerCallResponse `json:"owners"`
+	MatchScore *int32                      `json:"match_score,omitempty"`
+	NameScore  *int32                      `json:"name_score,omitempty"`
+	EmailScore *int32                      `json:"email_score,omitempty"`
+	UpdatedAt  *time.Time                  `json:"updated_at,omitempty"`
+}
+
+/*
+newItemCallResponse maps a link item to its client view, which never carries the access token or Plaid item ID
+*/
+func newItemCallResponse(item link.LinkItem) ItemCallResponse {
+	return ItemCallResponse{
+		ID:                    item.ID,
+		InstitutionID:         item.InstitutionID.String,
+		InstitutionName:       item.InstitutionName.String,
+		Status:                string(item.Status),
+		ErrorCode:             item.ErrorCode.String,
+		ErrorMessage:          item.ErrorMessage.String,
+		ConsentExpirationTime: timestampPtr(item.ConsentExpirationTime),
+		LastSuccessfulUpdate:  timestampPtr(item.LastSuccessfulUpdate),
+		LastFailedUpdate:      timestampPtr(item.LastFailedUpdate),
+		CreatedAt:             item.CreatedAt.Time,
+	}
+}
+
+func timestampPtr(timestamp pgtype.Timestamptz) *time.Time {
+	if !timestamp.Valid {
+		return nil
+	}
+	return &timestamp.Time
+}
+
+/*
+newAccountCallResponse maps a link account to its client view, which never carries the Plaid account ID
+*/
+func newAccountCallResponse(account link.LinkAccount) AccountCallResponse {
+	return AccountCallResponse{
+		ID:                 account.ID,
+		ItemID:             account.ItemID,
+		Name:               account.Name.String,
+		OfficialName:       account.OfficialName.String,
+		Mask:               account.Mask.String,
+		Type:               account.Type.String,
+		Subtype:            account.Subtype.String,
+		ClosedAt:           timestampPtr(account.ClosedAt),
+		IdentityMatchScore: int4Ptr(account.IdentityMatchScore),
+		IdentityNameScore:  int4Ptr(account.IdentityNameScore),
+		IdentityEmailScore: int4Ptr(account.IdentityEmailScore),
+		IdentityUpdatedAt:  timestampPtr(account.IdentityUpdatedAt),
+		CreatedAt:          account.CreatedAt.Time,
+		UpdatedAt:          account.UpdatedAt.Time,
+	}
+}
+
+/*
+newIdentityCallResponse maps the decrypted identity of an account to its client view
+*/
+func newIdentityCallResponse(identity *link.AccountIdentity) IdentityCallResponse {
+	owners := make([]IdentityOwnerCallResponse, 0, len(identity.Owners))
+	for _, owner := range identity.Owners {
+		owners = append(owners, IdentityOwnerCallResponse{
+			Names:        owner.Names,
+			Emails:       owner.Emails,
+			PhoneNumbers: owner.PhoneNumbers,
+			Addresses:    owner.Addresses,
+		})
+	}
+
+	return IdentityCallResponse{
+		AccountID:  identity.AccountID,
+		Owners:     owners,
+		MatchScore: int4Ptr(identity.MatchScore),
+		NameScore:  int4Ptr(identity.NameScore),
+		EmailScore: int4Ptr(identity.EmailScore),
+		UpdatedAt:  timestampPtr(identity.UpdatedAt),
+	}
+}
+
+func newAccountNumbersCallResponse(numbers link.AccountNumbers) AccountNumbersCallResponse {
+	return AccountNumbersCallResponse{
+		AccountID:         numbers.AccountID,
+		Network:           numbers.Network,
+		AccountNumber:     numbers.AccountNumber,
+		RoutingNumber:     numbers.RoutingNumber,
+		WireRoutingNumber: numbers.WireRoutingNumber,
+		BranchNumber:      numbers.BranchNumber,
+		IsTokenized:       numbers.IsTokenized,
+		Masked:            numbers.Masked,
+		UpdatedAt:         timestampPtr(numbers.UpdatedAt),
+	}
+}
+
+func newProcessorTokenCallResponse(processorToken link.ProcessorToken) ProcessorTokenCallResponse {
+	return ProcessorTokenCallResponse{
+		ID:        processorToken.ID,
+		ItemID:    int8Ptr(processorToken.ItemID),
+		AccountID: int8Ptr(processorToken.AccountID),
+		Processor: processorToken.Processor,
+		RevokedAt: timestampPtr(processorToken.RevokedAt),
+		CreatedAt: timestampPtr(processorToken.CreatedAt),
+	}
+}
+
+func newTransferCallResponse(transfer link.Transfer) TransferCallResponse {
+	return TransferCallResponse{
+		ID:                 transfer.ID,
+		AccountID:          int8Ptr(transfer.AccountID),
+		TransferID:         transfer.TransferID.String,
+		Type:               transfer.Type,
+		Amount:             numericPtr(transfer.Amount),
+		Description:        transfer.Description,
+		Status:             string(transfer.Status),
+		FailureCode:        transfer.FailureCode.String,
+		AchReturnCode:      transfer.AchReturnCode.String,
+		FailureDescription: transfer.FailureDescription.String,
+		CreatedAt:          timestampPtr(transfer.CreatedAt),
+		UpdatedAt:          timestampPtr(transfer.UpdatedAt),
+	}
+}
+
+func int8Ptr(value pgtype.Int8) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int64
+}
+
+func int4Ptr(value pgtype.Int4) *int32 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int32
+}
+
+func newTransactionCallResponse(transaction link.LinkTransaction) TransactionCallResponse {
+	response := TransactionCallResponse{
+		ID:                   transaction.ID,
+		TransactionID:        transaction.TransactionID,
+		AccountID:            transaction.AccountID,
+		IsoCurrencyCode:      transaction.IsoCurrencyCode.String,
+		Name:                 transaction.Name.String,
+		MerchantName:         transaction.MerchantName.String,
+		PaymentChannel:       transaction.PaymentChannel.String,
+		Category:             transaction.Category.String,
+		CategoryDetailed:     transaction.CategoryDetailed.String,
+		Pending:              transaction.Pending,
+		PendingTransactionID: transaction.PendingTransactionID.String,
+	}
+
+	if amount, err := transaction.Amount.Float64Value(); err == nil {
+		response.Amount = amount.Float64
+	}
+	if transaction.Date.Valid {
+		response.Date = transaction.Date.Time.Format(dateLayout)
+	}
+	if transaction.AuthorizedDate.Valid {
+		response.AuthorizedDate = transaction.AuthorizedDate.Time.Format(dateLayout)
+	}
+
+	return response
+}
+
+func newBalanceCallResponse(balance link.AccountBalanceSnapshot) BalanceCallResponse {
+	return BalanceCallResponse{
+		ID:                     balance.ID,
+		AccountID:              balance.AccountID,
+		Current:                numericPtr(balance.CurrentBalance),
+		Available:              numericPtr(balance.AvailableBalance),
+		Limit:                  numericPtr(balance.BalanceLimit),
+		IsoCurrencyCode:        balance.IsoCurrencyCode.String,
+		UnofficialCurrencyCode: balance.UnofficialCurrencyCode.String,
+		Source:                 balance.Source,
+		CapturedAt:             balance.CapturedAt.Time,
+	}
+}
+
+func numericPtr(numeric pgtype.Numeric) *float64 {
+	value, err := numeric.Float64Value()
+	if err != nil || !value.Valid {
+		return nil
+	}
+	return &value.Float64
 }
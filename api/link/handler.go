@@ -2,15 +2,35 @@ package link
 
 import (
 	"driftGo/api/common/errors"
+	"driftGo/api/common/utils"
 	"driftGo/api/common/validation"
+	"driftGo/api/middleware"
 	"driftGo/domain/link"
 	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/schema"
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	defaultTransactionsLimit = 100
+	defaultTransfersLimit    = 100
+	defaultBalanceHistory    = 30 * 24 * time.Hour
+)
+
+var decoder *schema.Decoder = schema.NewDecoder()
+
+func init() {
+	decoder.IgnoreUnknownKeys(true)
+}
+
 /*
 Handler holds the service instance for handling Plaid link-related operations
 */
@@ -26,17 +46,76 @@ func SetupRoutes(r chi.Router, service *link.Service) {
 	handler := &Handler{service: service}
 	r.Post("/create", handler.createLinkToken)
 	r.Post("/exchange", handler.exchangePublicToken)
-	r.Post("/createStripeProcessorToken", handler.createStripeProcessorToken)
+	r.With(middleware.RequireSecondFactor).Post("/createStripeProcessorToken", handler.createStripeProcessorToken)
+	r.Route("/processor-tokens", func(r chi.Router) {
+		r.Get("/", handler.getProcessorTokens)
+		r.With(middleware.RequireSecondFactor).Post("/", handler.createProcessorToken)
+		r.With(middleware.RequireStepUp).Put("/{id}/permissions", handler.setProcessorTokenPermissions)
+		r.With(middleware.RequireStepUp).Post("/{id}/unlink-item", handler.unlinkProcessorTokenItem)
+	})
+	r.Route("/items", func(r chi.Router) {
+		r.Get("/", handler.getItems)
+		r.Delete("/{id}", handler.deleteItem)
+		r.Get("/{id}/accounts", handler.getItemAccounts)
+		r.Post("/{id}/accounts/refresh", handler.refreshItemAccounts)
+		r.Post("/{id}/identity/refresh", handler.refreshItemIdentity)
+		r.Post("/{id}/auth/refresh", handler.refreshItemAuth)
+		r.Post("/{id}/update-token", handler.createUpdateLinkToken)
+		r.Post("/{id}/update-complete", handler.completeItemUpdate)
+	})
+	r.Route("/accounts", func(r chi.Router) {
+		r.Get("/", handler.getAccounts)
+		r.Get("/{id}/balances", handler.getAccountBalances)
+		r.Post("/{id}/balances/refresh", handler.refreshAccountBalance)
+		r.Get("/{id}/identity", handler.getAccountIdentity)
+		r.Get("/{id}/numbers", handler.getAccountNumbers)
+		r.With(middleware.RequireSecondFactor).Get("/{id}/numbers/reveal", handler.revealAccountNumbers)
+	})
+	r.Route("/transfers", func(r chi.Router) {
+		r.Get("/", handler.getTransfers)
+		r.Get("/{id}", handler.getTransfer)
+		r.With(middleware.RequireStepUp).Post("/", handler.createTransfer)
+	})
+	r.Route("/transactions", func(r chi.Router) {
+		r.Get("/", handler.getTransactions)
+		r.Post("/sync", handler.syncTransactions)
+	})
 }
 
 /*
 createLinkToken handles the request to create a new Plaid link token.
 This is used to initialize the Plaid Link interface for a user.
 The link token is required to start the Plaid Link flow.
+The optional request body overrides the configured products, account filters, webhook and redirect URI,
+limited to the values on the configured allowlist.
 */
 func (h *Handler) createLinkToken(w http.ResponseWriter, r *http.Request) {
-	linkToken, err := h.service.CreateLinkToken(r.Context())
+	var createLinkTokenCallRequest CreateLinkTokenCallRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&createLinkTokenCallRequest); err != nil && err != io.EOF {
+		log.WithError(err).Error("Failed to decode create link token request")
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, createLinkTokenCallRequest) {
+		return
+	}
+
+	linkToken, err := h.service.CreateLinkToken(r.Context(), link.LinkTokenOptions{
+		Products:                    createLinkTokenCallRequest.Products,
+		OptionalProducts:            createLinkTokenCallRequest.OptionalProducts,
+		RequiredIfSupportedProducts: createLinkTokenCallRequest.RequiredIfSupportedProducts,
+		AccountFilters:              createLinkTokenCallRequest.AccountFilters,
+		WebhookURL:                  createLinkTokenCallRequest.Webhook,
+		RedirectURI:                 createLinkTokenCallRequest.RedirectURI,
+	})
 	if err != nil {
+		var optionErr *link.LinkTokenOptionError
+		if stderrors.As(err, &optionErr) {
+			errors.ValidationErrorHandler(w, optionErr.Error())
+			return
+		}
 		log.WithError(err).Error("Failed to create link token")
 		errors.RequestErrorHandler(w, errors.NewErrorWithCode(http.StatusInternalServerError, "Failed to create link token", errors.ErrCodeInternalError))
 		return
@@ -54,6 +133,7 @@ func (h *Handler) createLinkToken(w http.ResponseWriter, r *http.Request) {
 exchangePublicToken handles the request to exchange a public token for an access token and save it to the database.
 This is used after a user successfully links their bank account through Plaid Link.
 The public token is exchanged for an access token that can be used to access the user's bank account data.
+If the user already linked the same accounts, a 409 carrying the existing item ID is returned instead.
 */
 func (h *Handler) exchangePublicToken(w http.ResponseWriter, r *http.Request) {
 	var exchangePublicTokenCallRequest ExchangePublicTokenCallRequest
@@ -68,20 +148,31 @@ func (h *Handler) exchangePublicToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.service.ExchangePublicTokenAndSave(r.Context(), exchangePublicTokenCallRequest.PublicToken)
+	item, err := h.service.ExchangePublicTokenAndSave(r.Context(), exchangePublicTokenCallRequest.PublicToken)
 	if err != nil {
+		var duplicate *link.DuplicateItemError
+		if stderrors.As(err, &duplicate) {
+			errors.DuplicateItemErrorHandler(w, duplicate.ExistingItemID)
+			return
+		}
 		log.WithError(err).Error("Failed to exchange public token and save")
 		errors.InternalErrorHandler(w)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newItemCallResponse(*item)); err != nil {
+		log.WithError(err).Error("Failed to encode item response")
+		errors.InternalErrorHandler(w)
+		return
+	}
 }
 
 /*
 createStripeProcessorToken handles the request to create a new Stripe processor token.
 This is used to create a new Stripe processor token for a user's bank account.
-The processor token is required to create a new Stripe customer.
+The token is returned and, when requested, attached to the user's Stripe Customer as a bank account source.
+The route is wrapped in RequireSecondFactor.
 */
 func (h *Handler) createStripeProcessorToken(w http.ResponseWriter, r *http.Request) {
 	var createStripeProcessorTokenCallRequest CreateStripeProcessorTokenCallRequest
@@ -96,22 +187,850 @@ func (h *Handler) createStripeProcessorToken(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	accessToken, err := h.service.GetAccessTokenByAccountID(r.Context(), createStripeProcessorTokenCallRequest.AccountID)
+	bankAccount, err := h.service.CreateStripeProcessorToken(r.Context(), createStripeProcessorTokenCallRequest.AccountID, createStripeProcessorTokenCallRequest.AttachToCustomer)
 	if err != nil {
-		log.WithError(err).Error("Failed to get access token by plaid account id")
+		var declined *link.DebitDeclinedError
+		if stderrors.As(err, &declined) {
+			errors.DebitDeclinedErrorHandler(w, declined.Reason, declined.CheckID)
+			return
+		}
+		switch err {
+		case link.ErrStripeNotConfigured:
+			errors.ValidationErrorHandler(w, "Stripe attachment is not available")
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Account not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to create stripe processor token")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	response := StripeProcessorTokenCallResponse{
+		AccountID:              bankAccount.AccountID,
+		StripeBankAccountToken: bankAccount.Token,
+		StripeCustomerID:       bankAccount.StripeCustomerID,
+		StripeBankAccountID:    bankAccount.StripeBankAccountID,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("Failed to encode stripe processor token response")
 		errors.InternalErrorHandler(w)
 		return
 	}
+}
+
+/*
+getTransactions handles the request to list the stored transactions of the authenticated user.
+Transactions are returned newest first and can be paged with the limit and offset query parameters.
+*/
+func (h *Handler) getTransactions(w http.ResponseWriter, r *http.Request) {
+	var getTransactionsCallRequest GetTransactionsCallRequest
+
+	if err := decoder.Decode(&getTransactionsCallRequest, r.URL.Query()); err != nil {
+		log.WithError(err).Error("Failed to decode get transactions request")
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
 
-	stripeProcessorToken, err := h.service.CreateStripeProcessorToken(r.Context(), accessToken, createStripeProcessorTokenCallRequest.AccountID)
+	if !validation.ValidateRequest(w, getTransactionsCallRequest) {
+		return
+	}
+
+	if getTransactionsCallRequest.Limit == 0 {
+		getTransactionsCallRequest.Limit = defaultTransactionsLimit
+	}
+
+	transactions, err := h.service.GetLinkTransactionsByUser(r.Context(), getTransactionsCallRequest.Limit, getTransactionsCallRequest.Offset)
 	if err != nil {
-		log.WithError(err).Error("Failed to create stripe processor token")
+		log.WithError(err).Error("Failed to get transactions")
+		errors.InternalErrorHandler(w)
+		return
+	}
+
+	response := make([]TransactionCallResponse, 0, len(transactions))
+	for _, transaction := range transactions {
+		response = append(response, newTransactionCallResponse(transaction))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("Failed to encode transactions response")
 		errors.InternalErrorHandler(w)
 		return
 	}
+}
+
+/*
+syncTransactions handles the request to pull the latest transactions from Plaid.
+It runs /transactions/sync for every item of the authenticated user from the item's stored cursor.
+*/
+func (h *Handler) syncTransactions(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.SyncTransactionsForUser(r.Context()); err != nil {
+		log.WithError(err).Error("Failed to sync transactions")
+		errors.InternalErrorHandler(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/*
+deleteItem handles the request to disconnect a linked item.
+The item must belong to the authenticated user. It is removed at Plaid and deleted together with its accounts.
+*/
+func (h *Handler) deleteItem(w http.ResponseWriter, r *http.Request) {
+	itemID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errors.ValidationErrorHandler(w, "Invalid item id")
+		return
+	}
 
-	//remove after testing
-	log.WithField("stripe_processor_token", stripeProcessorToken).Info("Stripe processor token created")
+	if err := h.service.UnlinkItem(r.Context(), itemID); err != nil {
+		switch err {
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Item not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to unlink item")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
 
 	w.WriteHeader(http.StatusOK)
 }
+
+/*
+getItems handles the request to list the items the authenticated user has linked.
+*/
+func (h *Handler) getItems(w http.ResponseWriter, r *http.Request) {
+	items, err := h.service.GetLinkItemsByUser(r.Context())
+	if err != nil {
+		log.WithError(err).Error("Failed to get items")
+		errors.InternalErrorHandler(w)
+		return
+	}
+
+	response := make([]ItemCallResponse, 0, len(items))
+	for _, item := range items {
+		response = append(response, newItemCallResponse(item))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("Failed to encode items response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+getItemAccounts handles the request to list the accounts of one linked item.
+The item must belong to the authenticated user.
+*/
+func (h *Handler) getItemAccounts(w http.ResponseWriter, r *http.Request) {
+	itemID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errors.ValidationErrorHandler(w, "Invalid item id")
+		return
+	}
+
+	accounts, err := h.service.GetLinkAccountsByUserItem(r.Context(), itemID)
+	if err != nil {
+		switch err {
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Item not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to get item accounts")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	h.writeAccounts(w, accounts)
+}
+
+/*
+refreshItemAccounts handles the request to reconcile the stored accounts of one linked item with Plaid.
+Changed accounts are updated, new accounts are added and accounts Plaid no longer returns are marked closed.
+*/
+func (h *Handler) refreshItemAccounts(w http.ResponseWriter, r *http.Request) {
+	itemID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errors.ValidationErrorHandler(w, "Invalid item id")
+		return
+	}
+
+	accounts, err := h.service.RefreshItemAccounts(r.Context(), itemID)
+	if err != nil {
+		switch err {
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Item not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to refresh item accounts")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	h.writeAccounts(w, accounts)
+}
+
+/*
+getAccounts handles the request to list every account the authenticated user has linked.
+*/
+func (h *Handler) getAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := h.service.GetLinkAccountsByUser(r.Context())
+	if err != nil {
+		log.WithError(err).Error("Failed to get accounts")
+		errors.InternalErrorHandler(w)
+		return
+	}
+
+	h.writeAccounts(w, accounts)
+}
+
+func (h *Handler) writeAccounts(w http.ResponseWriter, accounts []link.LinkAccount) {
+	response := make([]AccountCallResponse, 0, len(accounts))
+	for _, account := range accounts {
+		response = append(response, newAccountCallResponse(account))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("Failed to encode accounts response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+createUpdateLinkToken handles the request to create a Plaid link token in update mode.
+This is used when an item needs the user to log in again, so the existing item is repaired instead of linked twice.
+The optional request body can enable account selection so the user can share newly opened accounts.
+*/
+func (h *Handler) createUpdateLinkToken(w http.ResponseWriter, r *http.Request) {
+	itemID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errors.ValidationErrorHandler(w, "Invalid item id")
+		return
+	}
+
+	var createUpdateLinkTokenCallRequest CreateUpdateLinkTokenCallRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&createUpdateLinkTokenCallRequest); err != nil && err != io.EOF {
+		log.WithError(err).Error("Failed to decode create update link token request")
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	linkToken, err := h.service.CreateUpdateLinkToken(r.Context(), itemID, createUpdateLinkTokenCallRequest.AccountSelectionEnabled)
+	if err != nil {
+		switch err {
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Item not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to create update link token")
+			errors.RequestErrorHandler(w, errors.NewErrorWithCode(http.StatusInternalServerError, "Failed to create link token", errors.ErrCodeInternalError))
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(linkToken); err != nil {
+		log.WithError(err).Error("Failed to encode link token response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+completeItemUpdate handles the request sent after the user finishes Plaid Link in update mode.
+It clears the stored error of the item once Plaid confirms the item is healthy again.
+*/
+func (h *Handler) completeItemUpdate(w http.ResponseWriter, r *http.Request) {
+	itemID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errors.ValidationErrorHandler(w, "Invalid item id")
+		return
+	}
+
+	if err := h.service.CompleteItemUpdate(r.Context(), itemID); err != nil {
+		switch err {
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Item not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		case link.ErrItemUpdateRequired:
+			errors.ConflictErrorHandler(w, "Item still requires the user to update it")
+		default:
+			log.WithError(err).Error("Failed to complete item update")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/*
+getAccountBalances handles the request to list the balance history of one linked account.
+The from and to query parameters are inclusive dates and default to the last 30 days.
+*/
+func (h *Handler) getAccountBalances(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errors.ValidationErrorHandler(w, "Invalid account id")
+		return
+	}
+
+	var getBalancesCallRequest GetBalancesCallRequest
+
+	if err := decoder.Decode(&getBalancesCallRequest, r.URL.Query()); err != nil {
+		log.WithError(err).Error("Failed to decode get balances request")
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, getBalancesCallRequest) {
+		return
+	}
+
+	to := time.Now()
+	if getBalancesCallRequest.To != "" {
+		// Dates are inclusive, so the range ends at the start of the following day
+		to, _ = time.Parse(dateLayout, getBalancesCallRequest.To)
+		to = to.AddDate(0, 0, 1)
+	}
+
+	from := to.Add(-defaultBalanceHistory)
+	if getBalancesCallRequest.From != "" {
+		from, _ = time.Parse(dateLayout, getBalancesCallRequest.From)
+	}
+
+	if !from.Before(to) {
+		errors.ValidationErrorHandler(w, "from must not be after to")
+		return
+	}
+
+	balances, err := h.service.GetAccountBalances(r.Context(), accountID, from, to)
+	if err != nil {
+		switch err {
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Account not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to get account balances")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	response := make([]BalanceCallResponse, 0, len(balances))
+	for _, balance := range balances {
+		response = append(response, newBalanceCallResponse(balance))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("Failed to encode balances response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+refreshAccountBalance handles the request to fetch the current balance of one linked account from Plaid.
+The balance is cached by Plaid unless realtime is set in the optional request body.
+*/
+func (h *Handler) refreshAccountBalance(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errors.ValidationErrorHandler(w, "Invalid account id")
+		return
+	}
+
+	var refreshBalanceCallRequest RefreshBalanceCallRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&refreshBalanceCallRequest); err != nil && err != io.EOF {
+		log.WithError(err).Error("Failed to decode refresh balance request")
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	balance, err := h.service.RefreshAccountBalance(r.Context(), accountID, refreshBalanceCallRequest.Realtime)
+	if err != nil {
+		switch err {
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Account not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to refresh account balance")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newBalanceCallResponse(*balance)); err != nil {
+		log.WithError(err).Error("Failed to encode balance response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+refreshItemIdentity handles the request to fetch the account owners of one linked item from Plaid Identity.
+The owners are stored encrypted and scored against the user's profile. The updated accounts carry the scores.
+*/
+func (h *Handler) refreshItemIdentity(w http.ResponseWriter, r *http.Request) {
+	itemID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errors.ValidationErrorHandler(w, "Invalid item id")
+		return
+	}
+
+	accounts, err := h.service.RefreshItemIdentity(r.Context(), itemID)
+	if err != nil {
+		switch err {
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Item not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to refresh item identity")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	h.writeAccounts(w, accounts)
+}
+
+/*
+getAccountIdentity handles the request to read the stored owners of one linked account and their match against the user's profile.
+*/
+func (h *Handler) getAccountIdentity(w http.ResponseWriter, r *http.Request) {
+	accountID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errors.ValidationErrorHandler(w, "Invalid account id")
+		return
+	}
+
+	identity, err := h.service.GetAccountIdentity(r.Context(), accountID)
+	if err != nil {
+		switch err {
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Account identity not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to get account identity")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newIdentityCallResponse(identity)); err != nil {
+		log.WithError(err).Error("Failed to encode identity response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+refreshItemAuth handles the request to fetch the ACH and EFT numbers of one linked item from Plaid Auth.
+The numbers are stored encrypted and only returned masked.
+*/
+func (h *Handler) refreshItemAuth(w http.ResponseWriter, r *http.Request) {
+	itemID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errors.ValidationErrorHandler(w, "Invalid item id")
+		return
+	}
+
+	accountNumbers, err := h.service.RefreshItemAuth(r.Context(), itemID)
+	if err != nil {
+		switch err {
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Item not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to refresh item auth")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	response := make([]AccountNumbersCallResponse, 0, len(accountNumbers))
+	for _, numbers := range accountNumbers {
+		response = append(response, newAccountNumbersCallResponse(numbers))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("Failed to encode account numbers response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+getAccountNumbers handles the request to read the masked ACH or EFT numbers of one linked account.
+*/
+func (h *Handler) getAccountNumbers(w http.ResponseWriter, r *http.Request) {
+	h.writeAccountNumbers(w, r, false)
+}
+
+/*
+revealAccountNumbers handles the request to read the full ACH or EFT numbers of one linked account.
+The route is wrapped in RequireSecondFactor, so the session must have a recent TOTP or recovery code.
+*/
+func (h *Handler) revealAccountNumbers(w http.ResponseWriter, r *http.Request) {
+	h.writeAccountNumbers(w, r, true)
+}
+
+func (h *Handler) writeAccountNumbers(w http.ResponseWriter, r *http.Request, reveal bool) {
+	accountID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errors.ValidationErrorHandler(w, "Invalid account id")
+		return
+	}
+
+	numbers, err := h.service.GetAccountNumbers(r.Context(), accountID, reveal)
+	if err != nil {
+		switch err {
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Account numbers not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to get account numbers")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	if reveal {
+		log.WithFields(log.Fields{"user_id": utils.GetUserID(r.Context()), "link_account_id": accountID}).Info("Account numbers revealed")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newAccountNumbersCallResponse(*numbers)); err != nil {
+		log.WithError(err).Error("Failed to encode account numbers response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+createProcessorToken creates a processor token for one of the user's accounts for a configured payments partner.
+The route is wrapped in RequireSecondFactor.
+*/
+func (h *Handler) createProcessorToken(w http.ResponseWriter, r *http.Request) {
+	var createProcessorTokenCallRequest CreateProcessorTokenCallRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&createProcessorTokenCallRequest); err != nil {
+		log.WithError(err).Error("Failed to decode create processor token request")
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, createProcessorTokenCallRequest) {
+		return
+	}
+
+	processorToken, token, err := h.service.CreateProcessorToken(r.Context(), createProcessorTokenCallRequest.AccountID, createProcessorTokenCallRequest.Processor)
+	if err != nil {
+		var declined *link.DebitDeclinedError
+		if stderrors.As(err, &declined) {
+			errors.DebitDeclinedErrorHandler(w, declined.Reason, declined.CheckID)
+			return
+		}
+		switch err {
+		case link.ErrProcessorNotAllowed:
+			errors.ValidationErrorHandler(w, "Processor is not allowed")
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Account not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		case link.ErrItemUpdateRequired:
+			errors.ConflictErrorHandler(w, "Item requires update mode")
+		default:
+			log.WithError(err).Error("Failed to create processor token")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	response := newProcessorTokenCallResponse(*processorToken)
+	response.ProcessorToken = token
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("Failed to encode processor token response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+getProcessorTokens lists the processor tokens issued for the user's accounts, without the tokens themselves
+*/
+func (h *Handler) getProcessorTokens(w http.ResponseWriter, r *http.Request) {
+	processorTokens, err := h.service.GetProcessorTokensByUser(r.Context())
+	if err != nil {
+		log.WithError(err).Error("Failed to get processor tokens")
+		errors.InternalErrorHandler(w)
+		return
+	}
+
+	response := make([]ProcessorTokenCallResponse, 0, len(processorTokens))
+	for _, processorToken := range processorTokens {
+		response = append(response, newProcessorTokenCallResponse(processorToken))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("Failed to encode processor tokens response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+setProcessorTokenPermissions narrows the products a processor token can access.
+The route is wrapped in RequireStepUp.
+*/
+func (h *Handler) setProcessorTokenPermissions(w http.ResponseWriter, r *http.Request) {
+	processorTokenID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errors.ValidationErrorHandler(w, "Invalid processor token id")
+		return
+	}
+
+	var setProcessorTokenPermissionsCallRequest SetProcessorTokenPermissionsCallRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&setProcessorTokenPermissionsCallRequest); err != nil {
+		log.WithError(err).Error("Failed to decode processor token permissions request")
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, setProcessorTokenPermissionsCallRequest) {
+		return
+	}
+
+	err = h.service.SetProcessorTokenPermissions(r.Context(), processorTokenID, setProcessorTokenPermissionsCallRequest.Products)
+	if err != nil {
+		var optionErr *link.LinkTokenOptionError
+		if stderrors.As(err, &optionErr) {
+			errors.ValidationErrorHandler(w, optionErr.Error())
+			return
+		}
+		switch err {
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Processor token not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to set processor token permissions")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/*
+unlinkProcessorTokenItem removes the whole item a processor token was issued for,
+deleting its accounts and revoking every processor token of the item.
+The route is wrapped in RequireStepUp and responds with the IDs of the revoked tokens.
+*/
+func (h *Handler) unlinkProcessorTokenItem(w http.ResponseWriter, r *http.Request) {
+	processorTokenID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errors.ValidationErrorHandler(w, "Invalid processor token id")
+		return
+	}
+
+	unlink, err := h.service.UnlinkProcessorTokenItem(r.Context(), processorTokenID)
+	if err != nil {
+		switch err {
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Processor token not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to unlink processor token item")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(UnlinkProcessorTokenItemCallResponse{
+		ItemID:                   unlink.ItemID,
+		RevokedProcessorTokenIDs: unlink.ProcessorTokenIDs,
+	}); err != nil {
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+createTransfer funds from or withdraws to one of the user's accounts through Plaid Transfer.
+A debit pulls money from the account, a credit sends money to it, and the amount is in minor units.
+*/
+func (h *Handler) createTransfer(w http.ResponseWriter, r *http.Request) {
+	var createTransferCallRequest CreateTransferCallRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&createTransferCallRequest); err != nil {
+		log.WithError(err).Error("Failed to decode create transfer request")
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, createTransferCallRequest) {
+		return
+	}
+
+	ipAddress, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ipAddress = r.RemoteAddr
+	}
+
+	transfer, err := h.service.CreateTransfer(r.Context(), link.TransferRequest{
+		AccountID:   createTransferCallRequest.AccountID,
+		Type:        createTransferCallRequest.Type,
+		Amount:      createTransferCallRequest.Amount,
+		Description: createTransferCallRequest.Description,
+		IPAddress:   ipAddress,
+		UserAgent:   r.UserAgent(),
+	})
+	if err != nil {
+		var transferDeclined *link.TransferDeclinedError
+		if stderrors.As(err, &transferDeclined) {
+			errors.TransferDeclinedErrorHandler(w, transferDeclined.Decision, transferDeclined.Code, transferDeclined.Description)
+			return
+		}
+		var declined *link.DebitDeclinedError
+		if stderrors.As(err, &declined) {
+			errors.DebitDeclinedErrorHandler(w, declined.Reason, declined.CheckID)
+			return
+		}
+		switch err {
+		case link.ErrTransferLegalNameRequired:
+			errors.ValidationErrorHandler(w, "A first and last name are required to transfer funds")
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Account not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		case link.ErrItemUpdateRequired:
+			errors.ConflictErrorHandler(w, "Item requires update mode")
+		default:
+			log.WithError(err).Error("Failed to create transfer")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(newTransferCallResponse(*transfer)); err != nil {
+		log.WithError(err).Error("Failed to encode transfer response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+getTransfers handles the request to list the transfers of the authenticated user.
+Transfers are returned newest first and can be paged with the limit and offset query parameters.
+*/
+func (h *Handler) getTransfers(w http.ResponseWriter, r *http.Request) {
+	var getTransfersCallRequest GetTransfersCallRequest
+
+	if err := decoder.Decode(&getTransfersCallRequest, r.URL.Query()); err != nil {
+		log.WithError(err).Error("Failed to decode get transfers request")
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, getTransfersCallRequest) {
+		return
+	}
+
+	if getTransfersCallRequest.Limit == 0 {
+		getTransfersCallRequest.Limit = defaultTransfersLimit
+	}
+
+	transfers, err := h.service.GetTransfersByUser(r.Context(), getTransfersCallRequest.Limit, getTransfersCallRequest.Offset)
+	if err != nil {
+		log.WithError(err).Error("Failed to get transfers")
+		errors.InternalErrorHandler(w)
+		return
+	}
+
+	response := make([]TransferCallResponse, 0, len(transfers))
+	for _, transfer := range transfers {
+		response = append(response, newTransferCallResponse(transfer))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("Failed to encode transfers response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+getTransfer returns one of the user's transfers with its latest status from Plaid's transfer events.
+It returns 404 if the transfer does not exist and 403 if it belongs to another user.
+*/
+func (h *Handler) getTransfer(w http.ResponseWriter, r *http.Request) {
+	transferID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errors.ValidationErrorHandler(w, "Invalid transfer id")
+		return
+	}
+
+	transfer, err := h.service.GetTransfer(r.Context(), transferID)
+	if err != nil {
+		switch err {
+		case link.ErrTransferNotFound:
+			errors.NotFoundErrorHandler(w, "Transfer not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to get transfer")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newTransferCallResponse(*transfer)); err != nil {
+		log.WithError(err).Error("Failed to encode transfer response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"driftGo/domain/user"
 	"net/http"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
 )
 
 var (
@@ -16,11 +18,17 @@ var (
 		"/auth/login",
 		"/auth/create",
 		"/auth/authenticate/",
+		"/auth/password/reset/start",
+		"/auth/password/reset/complete",
+		"/auth/otp/",
 	}
 	authService *domauth.Service
 	userService user.UserInterface
 )
 
+// Response header carrying a refreshed session JWT
+const sessionJWTHeader = "X-Session-JWT"
+
 /*
 SetAuthService sets the auth service instance for the middleware
 */
@@ -35,6 +43,13 @@ func SetUserService(service user.UserInterface) {
 	userService = service
 }
 
+/*
+isJWT reports whether a bearer credential is a session JWT rather than an opaque session token
+*/
+func isJWT(credential string) bool {
+	return strings.Count(credential, ".") == 2
+}
+
 func isPublicRoute(path string) bool {
 	for _, prefix := range publicPrefixes {
 		if strings.HasPrefix(path, prefix) {
@@ -46,7 +61,8 @@ func isPublicRoute(path string) bool {
 
 /*
 AuthenticateSession is a middleware that checks if the request has a valid session token.
-If the token is valid, it adds the session token to the request context.
+The bearer credential may be a session token or a session JWT, which is verified locally while it is fresh.
+If the credential is valid, it adds it to the request context.
 If the token is invalid or missing, it returns a 401 Unauthorized error.
 This middleware is used to protect routes that require authentication.
 */
@@ -65,27 +81,53 @@ func AuthenticateSession(next http.Handler) http.Handler {
 			return
 		}
 
-		sessionToken := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer"))
+		credential := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer"))
 
-		response, err := authService.AuthenticateSession(r.Context(), sessionToken)
+		// Session JWTs are verified locally while fresh, opaque session tokens always go to Stytch
+		var response *sessions.AuthenticateResponse
+		var err error
+		authContext := utils.AuthContext{}
+		if isJWT(credential) {
+			authContext.SessionJWT = credential
+			response, err = authService.AuthenticateSessionJWT(r.Context(), credential)
+		} else {
+			authContext.SessionToken = credential
+			response, err = authService.AuthenticateSession(r.Context(), credential)
+		}
 		if err != nil {
 			log.WithError(err).Error("Invalid session")
 			errors.UnauthorizedErrorHandler(w, "Invalid session")
 			return
 		}
 
+		// A JWT checked with Stytch comes back refreshed, hand it to the client so it can be verified locally again
+		if authContext.SessionJWT != "" && response.SessionJWT != "" && response.SessionJWT != credential {
+			w.Header().Set(sessionJWTHeader, response.SessionJWT)
+			authContext.SessionJWT = response.SessionJWT
+		}
+
+		stytchUserID := response.Session.UserID
+
 		// Look up the internal user ID using the Stytch user ID
-		internalUser, err := userService.GetUserByStytchID(r.Context(), response.User.UserID)
+		internalUser, err := userService.GetUserByStytchID(r.Context(), stytchUserID)
 		if err != nil {
-			log.WithError(err).WithField("stytch_user_id", response.User.UserID).Error("Failed to find internal user")
+			log.WithError(err).WithField("stytch_user_id", stytchUserID).Error("Failed to find internal user")
 			errors.UnauthorizedErrorHandler(w, "User not found")
 			return
 		}
 
-		authContext := utils.AuthContext{
-			UserID:       internalUser.ID,
-			StytchUserID: response.User.UserID,
-			SessionToken: sessionToken,
+		authContext.UserID = internalUser.ID
+		authContext.StytchUserID = stytchUserID
+		for _, factor := range response.Session.AuthenticationFactors {
+			if factor.LastAuthenticatedAt == nil {
+				continue
+			}
+			if factor.LastAuthenticatedAt.After(authContext.AuthenticatedAt) {
+				authContext.AuthenticatedAt = *factor.LastAuthenticatedAt
+			}
+			if isSecondFactor(factor.Type) && factor.LastAuthenticatedAt.After(authContext.SecondFactorAt) {
+				authContext.SecondFactorAt = *factor.LastAuthenticatedAt
+			}
 		}
 		ctx := utils.WithAuthContext(r.Context(), authContext)
 
@@ -93,3 +135,45 @@ func AuthenticateSession(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+/*
+RequireStepUp is a middleware for sensitive routes that must run after AuthenticateSession.
+It only lets the request through if the session was authenticated within the last few minutes,
+otherwise it returns a 403 with the STEP_UP_REQUIRED code so the client can ask the user to log in again.
+*/
+func RequireStepUp(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if time.Since(utils.GetAuthenticatedAt(r.Context())) > domauth.StepUpMaxAge {
+			log.WithField("user_id", utils.GetUserID(r.Context())).Info("Step-up authentication required")
+			errors.StepUpRequiredErrorHandler(w, errors.MsgStepUpRequired)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+/*
+RequireSecondFactor is a middleware for the most sensitive routes that must run after AuthenticateSession.
+It only lets the request through if the session has an authenticator app code or recovery code
+authenticated within the last few minutes, otherwise it returns a 403 with the STEP_UP_REQUIRED code
+so the client can ask the user for a TOTP code.
+*/
+func RequireSecondFactor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if time.Since(utils.GetSecondFactorAt(r.Context())) > domauth.StepUpMaxAge {
+			log.WithField("user_id", utils.GetUserID(r.Context())).Info("Second factor required")
+			errors.StepUpRequiredErrorHandler(w, errors.MsgSecondFactorRequired)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+/*
+isSecondFactor reports whether a session factor proves possession of an enrolled authenticator
+*/
+func isSecondFactor(factorType sessions.AuthenticationFactorType) bool {
+	return factorType == sessions.AuthenticationFactorTypeTOTP || factorType == sessions.AuthenticationFactorTypeRecoveryCodes
+}
@@ -0,0 +1,57 @@
+package payment
+
+import (
+	"driftGo/domain/payment"
+	"time"
+)
+
+type CreatePaymentCallRequest struct {
+	AccountID   int64  `json:"account_id" validate:"required"`
+	Amount      int64  `json:"amount" validate:"required,min=1"`
+	Currency    string `json:"currency" validate:"omitempty,oneof=usd USD"`
+	Description string `json:"description" validate:"omitempty,max=500"`
+}
+
+type GetPaymentsCallRequest struct {
+	Limit  int32 `schema:"limit" validate:"omitempty,min=1,max=500"`
+	Offset int32 `schema:"offset" validate:"omitempty,min=0"`
+}
+
+type PaymentCallResponse struct {
+	ID                    int64      `json:"id"`
+	AccountID             *int64     `json:"account_id,omitempty"`
+	Amount                int64      `json:"amount"`
+	Currency              string     `json:"currency"`
+	Description           string     `json:"description,omitempty"`
+	Status                string     `json:"status"`
+	StripePaymentIntentID string     `json:"stripe_payment_intent_id,omitempty"`
+	FailureCode           string     `json:"failure_code,omitempty"`
+	FailureMessage        string     `json:"failure_message,omitempty"`
+	CreatedAt             *time.Time `json:"created_at,omitempty"`
+	UpdatedAt             *time.Time `json:"updated_at,omitempty"`
+}
+
+func newPaymentCallResponse(p payment.Payment) PaymentCallResponse {
+	response := PaymentCallResponse{
+		ID:                    p.ID,
+		Amount:                p.Amount,
+		Currency:              p.Currency,
+		Description:           p.Description.String,
+		Status:                string(p.Status),
+		StripePaymentIntentID: p.StripePaymentIntentID.String,
+		FailureCode:           p.FailureCode.String,
+		FailureMessage:        p.FailureMessage.String,
+	}
+
+	if p.AccountID.Valid {
+		response.AccountID = &p.AccountID.Int64
+	}
+	if p.CreatedAt.Valid {
+		response.CreatedAt = &p.CreatedAt.Time
+	}
+	if p.UpdatedAt.Valid {
+		response.UpdatedAt = &p.UpdatedAt.Time
+	}
+
+	return response
+}
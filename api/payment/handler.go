@@ -0,0 +1,186 @@
+package payment
+
+import (
+	"driftGo/api/common/errors"
+	"driftGo/api/common/validation"
+	"driftGo/api/middleware"
+	"driftGo/domain/link"
+	"driftGo/domain/payment"
+	"encoding/json"
+	stderrors "errors"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/schema"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultPaymentsLimit = 100
+	defaultCurrency      = "usd"
+)
+
+var decoder *schema.Decoder = schema.NewDecoder()
+
+func init() {
+	decoder.IgnoreUnknownKeys(true)
+}
+
+/*
+Handler holds the service instance for handling payment operations
+*/
+type Handler struct {
+	service *payment.Service
+}
+
+/*
+SetupRoutes sets up the routes for the payment package.
+Creating a payment moves money, so it requires a recent authentication.
+*/
+func SetupRoutes(r chi.Router, service *payment.Service) {
+	handler := &Handler{service: service}
+	r.With(middleware.RequireStepUp).Post("/", handler.createPayment)
+	r.Get("/", handler.getPayments)
+	r.Get("/{id}", handler.getPayment)
+}
+
+/*
+createPayment debits one of the user's Stripe attached accounts by ACH.
+ACH debits of us_bank_account payment methods are USD only.
+ACH settles asynchronously, the returned payment is usually processing until Stripe reports the outcome by webhook.
+*/
+func (h *Handler) createPayment(w http.ResponseWriter, r *http.Request) {
+	var createPaymentCallRequest CreatePaymentCallRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&createPaymentCallRequest); err != nil {
+		log.WithError(err).Error("Failed to decode create payment request")
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, createPaymentCallRequest) {
+		return
+	}
+
+	if createPaymentCallRequest.Currency == "" {
+		createPaymentCallRequest.Currency = defaultCurrency
+	}
+
+	ipAddress, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ipAddress = r.RemoteAddr
+	}
+
+	createdPayment, err := h.service.CreateACHPayment(r.Context(), payment.ACHPaymentRequest{
+		AccountID:   createPaymentCallRequest.AccountID,
+		Amount:      createPaymentCallRequest.Amount,
+		Currency:    createPaymentCallRequest.Currency,
+		Description: createPaymentCallRequest.Description,
+		IPAddress:   ipAddress,
+		UserAgent:   r.UserAgent(),
+	})
+	if err != nil {
+		var declined *link.DebitDeclinedError
+		if stderrors.As(err, &declined) {
+			errors.DebitDeclinedErrorHandler(w, declined.Reason, declined.CheckID)
+			return
+		}
+		switch err {
+		case payment.ErrStripeNotConfigured:
+			errors.RequestErrorHandler(w, errors.NewErrorWithCode(http.StatusServiceUnavailable, "Payments are not available", errors.ErrCodeInternalError))
+		case link.ErrStripeBankAccountMissing:
+			errors.ValidationErrorHandler(w, "Account is not attached to Stripe")
+		case link.ErrLinkNotFound:
+			errors.NotFoundErrorHandler(w, "Account not found")
+		case link.ErrLinkForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to create payment")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(newPaymentCallResponse(*createdPayment)); err != nil {
+		log.WithError(err).Error("Failed to encode payment response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+getPayments lists the user's payments, newest first, paginated by limit and offset
+*/
+func (h *Handler) getPayments(w http.ResponseWriter, r *http.Request) {
+	var getPaymentsCallRequest GetPaymentsCallRequest
+
+	if err := decoder.Decode(&getPaymentsCallRequest, r.URL.Query()); err != nil {
+		log.WithError(err).Error("Failed to decode get payments request")
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if !validation.ValidateRequest(w, getPaymentsCallRequest) {
+		return
+	}
+
+	if getPaymentsCallRequest.Limit == 0 {
+		getPaymentsCallRequest.Limit = defaultPaymentsLimit
+	}
+
+	payments, err := h.service.GetPaymentsByUser(r.Context(), getPaymentsCallRequest.Limit, getPaymentsCallRequest.Offset)
+	if err != nil {
+		log.WithError(err).Error("Failed to get payments")
+		errors.InternalErrorHandler(w)
+		return
+	}
+
+	response := make([]PaymentCallResponse, 0, len(payments))
+	for _, p := range payments {
+		response = append(response, newPaymentCallResponse(p))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.WithError(err).Error("Failed to encode payments response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
+
+/*
+getPayment returns one of the user's payments by ID.
+It returns 404 if the payment does not exist and 403 if it belongs to another user.
+*/
+func (h *Handler) getPayment(w http.ResponseWriter, r *http.Request) {
+	paymentID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errors.ValidationErrorHandler(w, "Invalid payment id")
+		return
+	}
+
+	p, err := h.service.GetPayment(r.Context(), paymentID)
+	if err != nil {
+		switch err {
+		case payment.ErrPaymentNotFound:
+			errors.NotFoundErrorHandler(w, "Payment not found")
+		case payment.ErrPaymentForbidden:
+			errors.ForbiddenErrorHandler(w, errors.MsgForbidden)
+		default:
+			log.WithError(err).Error("Failed to get payment")
+			errors.InternalErrorHandler(w)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newPaymentCallResponse(*p)); err != nil {
+		log.WithError(err).Error("Failed to encode payment response")
+		errors.InternalErrorHandler(w)
+		return
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"driftGo/api/auth"
 	"driftGo/api/link"
 	validateSessionMiddleware "driftGo/api/middleware"
+	"driftGo/api/payment"
 	"driftGo/api/webhook"
 	"driftGo/pkg/logger"
 	"time"
@@ -36,6 +37,11 @@ func SetupRoutes(r *chi.Mux, services *Services) chi.Router {
 		protected.Route("/link", func(r chi.Router) {
 			link.SetupRoutes(r, services.Link)
 		})
+
+		// Setup payment routes
+		protected.Route("/payments", func(r chi.Router) {
+			payment.SetupRoutes(r, services.Payment)
+		})
 	})
 
 	return r
@@ -1,7 +1,11 @@
 package webhook
 
 import (
+	"driftGo/api/webhook/plaid"
+	"driftGo/api/webhook/stripe"
 	"driftGo/api/webhook/stytch"
+	"driftGo/domain/link"
+	"driftGo/domain/payment"
 	"driftGo/domain/user"
 
 	"github.com/go-chi/chi/v5"
@@ -12,14 +16,18 @@ WebhookHandler handles webhook requests.
 */
 type WebhookHandler struct {
 	stytchHandler *stytch.Handler
+	plaidHandler  *plaid.Handler
+	stripeHandler *stripe.Handler
 }
 
 /*
 NewWebhookHandler creates a new webhook handler.
 */
-func NewWebhookHandler(userService *user.Service, secret string) *WebhookHandler {
+func NewWebhookHandler(userService *user.Service, linkService *link.Service, paymentService *payment.Service, secret, stripeSecret string) *WebhookHandler {
 	return &WebhookHandler{
 		stytchHandler: stytch.NewHandler(userService, secret),
+		plaidHandler:  plaid.NewHandler(linkService),
+		stripeHandler: stripe.NewHandler(paymentService, stripeSecret),
 	}
 }
 
@@ -28,4 +36,6 @@ SetupRoutes sets up the webhook routes.
 */
 func SetupRoutes(r chi.Router, handler *WebhookHandler) {
 	r.Post("/stytch", handler.stytchHandler.HandleWebhook)
+	r.Post("/plaid", handler.plaidHandler.HandleWebhook)
+	r.Post("/stripe", handler.stripeHandler.HandleWebhook)
 }
@@ -0,0 +1,18 @@
+package plaid
+
+import "time"
+
+type WebhookEvent struct {
+	WebhookType           string        `json:"webhook_type"`
+	WebhookCode           string        `json:"webhook_code"`
+	ItemID                string        `json:"item_id"`
+	Environment           string        `json:"environment"`
+	Error                 *WebhookError `json:"error,omitempty"`
+	ConsentExpirationTime *time.Time    `json:"consent_expiration_time,omitempty"`
+}
+
+type WebhookError struct {
+	ErrorType    string `json:"error_type"`
+	ErrorCode    string `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+}
@@ -0,0 +1,106 @@
+package plaid
+
+import (
+	"driftGo/api/common/errors"
+	"driftGo/domain/link"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+Handler handles Plaid webhook events
+*/
+type Handler struct {
+	linkService *link.Service
+	verifier    *Verifier
+}
+
+/*
+NewHandler creates a new Plaid webhook handler
+*/
+func NewHandler(linkService *link.Service) *Handler {
+	return &Handler{
+		linkService: linkService,
+		verifier:    NewVerifier(linkService),
+	}
+}
+
+/*
+This function is used to handle the incoming Plaid webhook events.
+It reads the request body, verifies the Plaid-Verification JWT, parses the webhook event, and routes it based on the webhook type.
+
+Webhook types supported:
+- ITEM: Item errors, expiring consent and revoked access
+- TRANSACTIONS: New transaction data is available to sync
+- AUTH: Account and routing number verification updates
+- HOLDINGS: Investment holdings updates
+- TRANSFER: New transfer events are available to sync
+*/
+func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.WithError(err).Error("Failed to read webhook request body")
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if err := h.verifier.VerifySignature(r.Context(), r.Header, body); err != nil {
+		log.WithError(err).Error("Invalid webhook signature")
+		errors.RequestErrorHandler(w, errors.NewErrorWithCode(http.StatusUnauthorized, "Invalid webhook signature", errors.ErrCodeAuthentication))
+		return
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		log.WithError(err).Error("Failed to parse webhook event")
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	log.Info("Incoming plaid webhook event with type: ", event.WebhookType, " and code: ", event.WebhookCode)
+
+	switch event.WebhookType {
+	case link.WebhookTypeItem:
+		var itemError *link.ItemError
+		if event.Error != nil {
+			itemError = &link.ItemError{
+				ErrorType:    event.Error.ErrorType,
+				ErrorCode:    event.Error.ErrorCode,
+				ErrorMessage: event.Error.ErrorMessage,
+			}
+		}
+		err = h.linkService.HandleItemWebhook(r.Context(), event.ItemID, event.WebhookCode, itemError, event.ConsentExpirationTime)
+
+	case link.WebhookTypeTransactions:
+		err = h.linkService.HandleTransactionsWebhook(r.Context(), event.ItemID, event.WebhookCode)
+
+	case link.WebhookTypeAuth:
+		err = h.linkService.HandleAuthWebhook(r.Context(), event.ItemID, event.WebhookCode)
+
+	case link.WebhookTypeHoldings:
+		err = h.linkService.HandleHoldingsWebhook(r.Context(), event.ItemID, event.WebhookCode)
+
+	case link.WebhookTypeTransfer:
+		err = h.linkService.HandleTransferWebhook(r.Context(), event.WebhookCode)
+
+	default:
+		log.WithField("webhook_type", event.WebhookType).Info("Received unknown webhook type")
+	}
+
+	if err != nil {
+		if stderrors.Is(err, link.ErrLinkNotFound) {
+			log.WithField("item_id", event.ItemID).Warn("Received webhook for unknown item")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		log.WithError(err).Error("Failed to process plaid webhook")
+		errors.InternalErrorHandler(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
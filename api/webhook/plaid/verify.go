@@ -0,0 +1,235 @@
+package plaid
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	plaidgo "github.com/plaid/plaid-go/v35/plaid"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	verificationHeader = "Plaid-Verification"
+	maxTokenAge        = 5 * time.Minute
+
+	// Plaid can expire or rotate a key at any time, so a cached key is only trusted this long before it is fetched again
+	keyCacheTTL = 10 * time.Minute
+
+	// Unknown key IDs come from unauthenticated requests, so Plaid is asked about them sparingly
+	failedKeyRetryInterval = time.Minute
+	keyFetchWindow         = time.Minute
+	maxKeyFetchesPerWindow = 10
+)
+
+var (
+	ErrMissingVerificationHeader = errors.New("missing Plaid-Verification header")
+	ErrStaleWebhook              = errors.New("webhook was issued more than 5 minutes ago")
+	ErrBodyHashMismatch          = errors.New("webhook body does not match the signed hash")
+	ErrExpiredVerificationKey    = errors.New("webhook verification key has expired")
+	ErrUnknownVerificationKey    = errors.New("webhook verification key is unknown")
+)
+
+/*
+KeyFetcher fetches a webhook verification key from Plaid by its key ID
+*/
+type KeyFetcher interface {
+	GetWebhookVerificationKey(ctx context.Context, keyID string) (*plaidgo.JWKPublicKey, error)
+}
+
+/*
+cachedKey is a verification key with the time it was fetched from Plaid
+*/
+type cachedKey struct {
+	key       *plaidgo.JWKPublicKey
+	fetchedAt time.Time
+}
+
+/*
+Verifier checks the Plaid-Verification JWT sent with every Plaid webhook.
+Verification keys are cached by key ID and fetched again once they are older than keyCacheTTL,
+so a key Plaid expires later stops being trusted.
+Failed lookups are remembered for a minute, concurrent lookups of one key share a single fetch,
+and only a few fetches run per minute, so forged key IDs cannot spend the Plaid rate limit.
+*/
+type Verifier struct {
+	fetcher KeyFetcher
+	now     func() time.Time
+	group   singleflight.Group
+
+	mu            sync.RWMutex
+	keys          map[string]cachedKey
+	failedKeys    map[string]time.Time
+	windowStart   time.Time
+	windowFetches int
+}
+
+/*
+NewVerifier creates a new verifier that fetches unknown keys through the given fetcher
+*/
+func NewVerifier(fetcher KeyFetcher) *Verifier {
+	return &Verifier{
+		fetcher: fetcher,
+		now:     time.Now,
+		keys:    make(map[string]cachedKey),
+
+		failedKeys: make(map[string]time.Time),
+	}
+}
+
+/*
+VerifySignature validates the ES256 signature of the Plaid-Verification JWT, rejects tokens older
+than five minutes and checks that the SHA-256 of the body matches the request_body_sha256 claim.
+*/
+func (v *Verifier) VerifySignature(ctx context.Context, headers http.Header, body []byte) error {
+	tokenString := headers.Get(verificationHeader)
+	if tokenString == "" {
+		return ErrMissingVerificationHeader
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		keyID, ok := token.Header["kid"].(string)
+		if !ok || keyID == "" {
+			return nil, errors.New("missing kid in token header")
+		}
+		return v.publicKey(ctx, keyID)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg()}), jwt.WithTimeFunc(v.now))
+	if err != nil {
+		return fmt.Errorf("webhook verification failed: %w", err)
+	}
+
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return errors.New("webhook verification failed: missing iat claim")
+	}
+	if v.now().Sub(issuedAt.Time) > maxTokenAge {
+		return ErrStaleWebhook
+	}
+
+	claimedHash, _ := claims["request_body_sha256"].(string)
+	bodyHash := sha256.Sum256(body)
+	if subtle.ConstantTimeCompare([]byte(claimedHash), []byte(hex.EncodeToString(bodyHash[:]))) != 1 {
+		return ErrBodyHashMismatch
+	}
+
+	return nil
+}
+
+/*
+publicKey returns the cached key for the key ID, fetching it from Plaid on a cache miss or once the cached key is stale
+*/
+func (v *Verifier) publicKey(ctx context.Context, keyID string) (*ecdsa.PublicKey, error) {
+	v.mu.RLock()
+	key, ok := v.freshKey(keyID, v.now())
+	v.mu.RUnlock()
+
+	if !ok {
+		fetched, err, _ := v.group.Do(keyID, func() (interface{}, error) {
+			// The fetch is shared, so one caller going away must not fail the others
+			return v.fetchKey(context.WithoutCancel(ctx), keyID)
+		})
+		if err != nil {
+			return nil, err
+		}
+		key = fetched.(*plaidgo.JWKPublicKey)
+	}
+
+	if key.ExpiredAt.IsSet() && key.ExpiredAt.Get() != nil && int64(key.GetExpiredAt()) <= v.now().Unix() {
+		return nil, ErrExpiredVerificationKey
+	}
+
+	return toECDSAPublicKey(key)
+}
+
+/*
+freshKey returns the cached key for the key ID if it was fetched within keyCacheTTL, the caller must hold mu
+*/
+func (v *Verifier) freshKey(keyID string, now time.Time) (*plaidgo.JWKPublicKey, bool) {
+	cached, ok := v.keys[keyID]
+	if !ok || now.Sub(cached.fetchedAt) >= keyCacheTTL {
+		return nil, false
+	}
+	return cached.key, true
+}
+
+/*
+fetchKey asks Plaid for a key that is not cached or is stale, unless the key recently failed or too many keys were fetched lately
+*/
+func (v *Verifier) fetchKey(ctx context.Context, keyID string) (*plaidgo.JWKPublicKey, error) {
+	now := v.now()
+
+	v.mu.Lock()
+	if key, ok := v.freshKey(keyID, now); ok {
+		v.mu.Unlock()
+		return key, nil
+	}
+	if failedAt, ok := v.failedKeys[keyID]; ok && now.Sub(failedAt) < failedKeyRetryInterval {
+		v.mu.Unlock()
+		return nil, ErrUnknownVerificationKey
+	}
+	if now.Sub(v.windowStart) >= keyFetchWindow {
+		v.windowStart = now
+		v.windowFetches = 0
+	}
+	if v.windowFetches >= maxKeyFetchesPerWindow {
+		v.mu.Unlock()
+		return nil, ErrUnknownVerificationKey
+	}
+	v.windowFetches++
+	v.mu.Unlock()
+
+	fetched, err := v.fetcher.GetWebhookVerificationKey(ctx, keyID)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if err != nil {
+		// A stale key Plaid no longer returns must not stay trusted
+		delete(v.keys, keyID)
+		for failedKeyID, failedAt := range v.failedKeys {
+			if now.Sub(failedAt) >= failedKeyRetryInterval {
+				delete(v.failedKeys, failedKeyID)
+			}
+		}
+		v.failedKeys[keyID] = now
+		return nil, fmt.Errorf("failed to fetch verification key: %w", err)
+	}
+
+	delete(v.failedKeys, keyID)
+	v.keys[keyID] = cachedKey{key: fetched, fetchedAt: now}
+	return fetched, nil
+}
+
+func toECDSAPublicKey(key *plaidgo.JWKPublicKey) (*ecdsa.PublicKey, error) {
+	if key.GetCrv() != "P-256" {
+		return nil, fmt.Errorf("unsupported key curve: %s", key.GetCrv())
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(key.GetX())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key x coordinate: %w", err)
+	}
+
+	y, err := base64.RawURLEncoding.DecodeString(key.GetY())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key y coordinate: %w", err)
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
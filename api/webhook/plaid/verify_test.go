@@ -0,0 +1,166 @@
+package plaid
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	plaidgo "github.com/plaid/plaid-go/v35/plaid"
+)
+
+type fakeKeyFetcher struct {
+	key   *plaidgo.JWKPublicKey
+	err   error
+	calls int
+}
+
+func (f *fakeKeyFetcher) GetWebhookVerificationKey(ctx context.Context, keyID string) (*plaidgo.JWKPublicKey, error) {
+	f.calls++
+	return f.key, f.err
+}
+
+func newTestKey(t *testing.T) (*ecdsa.PrivateKey, *plaidgo.JWKPublicKey) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	jwk := plaidgo.NewJWKPublicKeyWithDefaults()
+	jwk.SetAlg("ES256")
+	jwk.SetCrv("P-256")
+	jwk.SetKid("test-key")
+	jwk.SetKty("EC")
+	jwk.SetUse("sig")
+	jwk.SetX(base64.RawURLEncoding.EncodeToString(privateKey.PublicKey.X.FillBytes(make([]byte, 32))))
+	jwk.SetY(base64.RawURLEncoding.EncodeToString(privateKey.PublicKey.Y.FillBytes(make([]byte, 32))))
+
+	return privateKey, jwk
+}
+
+func signWebhook(t *testing.T, privateKey *ecdsa.PrivateKey, body []byte, issuedAt time.Time) http.Header {
+	hash := sha256.Sum256(body)
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iat":                 issuedAt.Unix(),
+		"request_body_sha256": hex.EncodeToString(hash[:]),
+	})
+	token.Header["kid"] = "test-key"
+
+	signed, err := token.SignedString(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	headers := http.Header{}
+	headers.Set(verificationHeader, signed)
+	return headers
+}
+
+func TestVerifySignature(t *testing.T) {
+	privateKey, jwk := newTestKey(t)
+	fetcher := &fakeKeyFetcher{key: jwk}
+	verifier := NewVerifier(fetcher)
+	body := []byte(`{"webhook_type":"TRANSACTIONS","webhook_code":"SYNC_UPDATES_AVAILABLE"}`)
+
+	for i := 0; i < 2; i++ {
+		if err := verifier.VerifySignature(context.Background(), signWebhook(t, privateKey, body, time.Now()), body); err != nil {
+			t.Fatalf("Expected valid signature, got %v", err)
+		}
+	}
+
+	if fetcher.calls != 1 {
+		t.Fatalf("Expected verification key to be fetched once, got %d", fetcher.calls)
+	}
+}
+
+func TestVerifySignatureRejectsTamperedBody(t *testing.T) {
+	privateKey, jwk := newTestKey(t)
+	verifier := NewVerifier(&fakeKeyFetcher{key: jwk})
+	headers := signWebhook(t, privateKey, []byte(`{"item_id":"a"}`), time.Now())
+
+	err := verifier.VerifySignature(context.Background(), headers, []byte(`{"item_id":"b"}`))
+	if err != ErrBodyHashMismatch {
+		t.Fatalf("Expected ErrBodyHashMismatch, got %v", err)
+	}
+}
+
+func TestVerifySignatureRejectsStaleToken(t *testing.T) {
+	privateKey, jwk := newTestKey(t)
+	verifier := NewVerifier(&fakeKeyFetcher{key: jwk})
+	body := []byte(`{}`)
+
+	err := verifier.VerifySignature(context.Background(), signWebhook(t, privateKey, body, time.Now().Add(-10*time.Minute)), body)
+	if err != ErrStaleWebhook {
+		t.Fatalf("Expected ErrStaleWebhook, got %v", err)
+	}
+}
+
+func TestVerifySignatureRejectsMissingHeader(t *testing.T) {
+	_, jwk := newTestKey(t)
+	verifier := NewVerifier(&fakeKeyFetcher{key: jwk})
+
+	err := verifier.VerifySignature(context.Background(), http.Header{}, []byte(`{}`))
+	if err != ErrMissingVerificationHeader {
+		t.Fatalf("Expected ErrMissingVerificationHeader, got %v", err)
+	}
+}
+
+func TestVerifySignatureUnknownKey(t *testing.T) {
+	privateKey, _ := newTestKey(t)
+	fetcher := &fakeKeyFetcher{err: errors.New("key not found")}
+	verifier := NewVerifier(fetcher)
+	now := time.Now()
+	verifier.now = func() time.Time { return now }
+	body := []byte(`{}`)
+
+	for i := 0; i < 3; i++ {
+		if err := verifier.VerifySignature(context.Background(), signWebhook(t, privateKey, body, now), body); err == nil {
+			t.Fatal("Expected unknown key to fail verification")
+		}
+	}
+
+	if fetcher.calls != 1 {
+		t.Fatalf("Expected failed key to be fetched once, got %d", fetcher.calls)
+	}
+
+	now = now.Add(failedKeyRetryInterval)
+	verifier.VerifySignature(context.Background(), signWebhook(t, privateKey, body, now), body)
+	if fetcher.calls != 2 {
+		t.Fatalf("Expected failed key to be fetched again after the retry interval, got %d", fetcher.calls)
+	}
+}
+
+func TestVerifySignatureRefetchesStaleKey(t *testing.T) {
+	privateKey, jwk := newTestKey(t)
+	fetcher := &fakeKeyFetcher{key: jwk}
+	verifier := NewVerifier(fetcher)
+	now := time.Now()
+	verifier.now = func() time.Time { return now }
+	body := []byte(`{}`)
+
+	if err := verifier.VerifySignature(context.Background(), signWebhook(t, privateKey, body, now), body); err != nil {
+		t.Fatalf("Expected valid signature, got %v", err)
+	}
+
+	// Plaid expired the key after it was cached
+	expired := *jwk
+	expired.SetExpiredAt(int32(now.Unix()))
+	fetcher.key = &expired
+	now = now.Add(keyCacheTTL)
+
+	err := verifier.VerifySignature(context.Background(), signWebhook(t, privateKey, body, now), body)
+	if !errors.Is(err, ErrExpiredVerificationKey) {
+		t.Fatalf("Expected ErrExpiredVerificationKey, got %v", err)
+	}
+	if fetcher.calls != 2 {
+		t.Fatalf("Expected stale key to be fetched again, got %d fetches", fetcher.calls)
+	}
+}
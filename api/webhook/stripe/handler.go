@@ -0,0 +1,80 @@
+package stripe
+
+import (
+	"driftGo/api/common/errors"
+	"driftGo/domain/payment"
+	stripego "driftGo/pkg/stripe"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+Handler handles Stripe webhook events
+*/
+type Handler struct {
+	paymentService *payment.Service
+	secret         string
+}
+
+/*
+NewHandler creates a new Stripe webhook handler
+*/
+func NewHandler(paymentService *payment.Service, secret string) *Handler {
+	return &Handler{
+		paymentService: paymentService,
+		secret:         secret,
+	}
+}
+
+/*
+This function is used to handle the incoming Stripe webhook events.
+It reads the request body, verifies the Stripe-Signature header, parses the event, and updates the payment it refers to.
+
+Events supported:
+- payment_intent.processing: The ACH debit was submitted
+- payment_intent.succeeded: The ACH debit settled
+- payment_intent.payment_failed: The ACH debit failed or was returned
+- payment_intent.canceled: The payment was canceled
+- charge.dispute.created: The account holder disputed the debit
+*/
+func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.WithError(err).Error("Failed to read webhook request body")
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	if h.secret == "" {
+		log.Error("Stripe webhook received but STRIPE_WEBHOOK_SECRET is not set")
+		errors.RequestErrorHandler(w, errors.NewErrorWithCode(http.StatusUnauthorized, "Invalid webhook signature", errors.ErrCodeAuthentication))
+		return
+	}
+
+	if err := stripego.VerifySignature(h.secret, r.Header.Get(stripego.SignatureHeader), body, time.Now()); err != nil {
+		log.WithError(err).Error("Invalid webhook signature")
+		errors.RequestErrorHandler(w, errors.NewErrorWithCode(http.StatusUnauthorized, "Invalid webhook signature", errors.ErrCodeAuthentication))
+		return
+	}
+
+	var event stripego.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		log.WithError(err).Error("Failed to parse webhook event")
+		errors.RequestErrorHandler(w, errors.NewInvalidFormatError())
+		return
+	}
+
+	log.Info("Incoming stripe webhook event with type: ", event.Type, " and id: ", event.ID)
+
+	if err := h.paymentService.HandleStripeEvent(r.Context(), event); err != nil {
+		log.WithError(err).WithField("stripe_event_id", event.ID).Error("Failed to process stripe webhook")
+		errors.InternalErrorHandler(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
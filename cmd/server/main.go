@@ -4,6 +4,7 @@ import (
 	"context"
 	"driftGo/api"
 	"driftGo/config"
+	"driftGo/domain/link"
 	"driftGo/pkg/logger"
 	"net/http"
 	"os"
@@ -52,12 +53,20 @@ func main() {
 		}
 	}()
 
+	// Poll Plaid transfer events in case a TRANSFER webhook is missed
+	syncCtx, stopSync := context.WithCancel(context.Background())
+	defer stopSync()
+	if config.TransferEventSyncInterval > 0 {
+		go syncTransferEvents(syncCtx, services.Link, config.TransferEventSyncInterval)
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Println("Shutting down server...")
+	stopSync()
 
 	// Create a deadline for server shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -70,3 +79,22 @@ func main() {
 
 	log.Println("Server exited")
 }
+
+/*
+syncTransferEvents runs a transfer event sync on every tick until the context is cancelled
+*/
+func syncTransferEvents(ctx context.Context, linkService *link.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := linkService.SyncTransferEvents(ctx); err != nil {
+				log.WithError(err).Error("Failed to sync transfer events")
+			}
+		}
+	}
+}
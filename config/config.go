@@ -3,22 +3,52 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 var (
-	ProjectID          string
-	Secret             string
-	SignupMagicLinkURL string
-	Port               string
-	PlaidClientID      string
-	PlaidSecret        string
-	PlaidEnv           string
-	Env                string
-	DatabaseURL        string
-	WebhookSecret      string
-	EncryptionKey      string
+	ProjectID                string
+	Secret                   string
+	SignupMagicLinkURL       string
+	PasswordResetRedirectURL string
+	Port                     string
+	PlaidClientID            string
+	PlaidSecret              string
+	PlaidEnv                 string
+	Env                      string
+	DatabaseURL              string
+	WebhookSecret            string
+	EncryptionKey            string
+
+	StytchSessionJWTMaxAge time.Duration
+
+	StripeSecret        string
+	StripeBaseURL       string
+	StripeWebhookSecret string
+
+	PlaidClientName                  string
+	PlaidLanguage                    string
+	PlaidCountryCodes                []string
+	PlaidProducts                    []string
+	PlaidOptionalProducts            []string
+	PlaidRequiredIfSupportedProducts []string
+	PlaidWebhookURL                  string
+	PlaidRedirectURI                 string
+	PlaidAllowedProducts             []string
+	PlaidAllowedWebhookURLs          []string
+	PlaidAllowedRedirectURIs         []string
+	PlaidAllowedAccountTypes         []string
+	PlaidProcessors                  []string
+
+	TransferEventSyncInterval time.Duration
+
+	DebitMinRemainingBalance     float64
+	DebitMaxAmount               float64
+	DebitRequireAvailableBalance bool
 )
 
 func init() {
@@ -30,6 +60,7 @@ func init() {
 	ProjectID = os.Getenv("STYTCH_PROJECT_ID")
 	Secret = os.Getenv("STYTCH_SECRET")
 	SignupMagicLinkURL = os.Getenv("STYTCH_SIGNUP_REDIRECT_URL")
+	PasswordResetRedirectURL = os.Getenv("STYTCH_PASSWORD_RESET_REDIRECT_URL")
 	Port = os.Getenv("PORT")
 	PlaidClientID = os.Getenv("PLAID_CLIENT_ID")
 	PlaidSecret = os.Getenv("PLAID_SECRET")
@@ -39,6 +70,55 @@ func init() {
 	WebhookSecret = os.Getenv("STYTCH_WEBHOOK_SECRET")
 	encryptionKeyStr := os.Getenv("ENCRYPTION_KEY")
 
+	// Session JWTs younger than this are verified locally without calling Stytch, zero always calls Stytch
+	StytchSessionJWTMaxAge, err = time.ParseDuration(getEnvOrDefault("STYTCH_SESSION_JWT_MAX_AGE", "5m"))
+	if err != nil {
+		log.Fatal("Invalid STYTCH_SESSION_JWT_MAX_AGE: ", err)
+	}
+
+	StripeSecret = os.Getenv("STRIPE_SECRET")
+	StripeBaseURL = getEnvOrDefault("STRIPE_BASE_URL", "https://api.stripe.com")
+	StripeWebhookSecret = os.Getenv("STRIPE_WEBHOOK_SECRET")
+
+	// Link token defaults, lists are comma separated
+	PlaidClientName = getEnvOrDefault("PLAID_CLIENT_NAME", "drift")
+	PlaidLanguage = getEnvOrDefault("PLAID_LANGUAGE", "en")
+	PlaidCountryCodes = splitList(getEnvOrDefault("PLAID_COUNTRY_CODES", "US,CA"))
+	PlaidProducts = splitList(getEnvOrDefault("PLAID_PRODUCTS", "auth,identity"))
+	PlaidOptionalProducts = splitList(os.Getenv("PLAID_OPTIONAL_PRODUCTS"))
+	PlaidRequiredIfSupportedProducts = splitList(os.Getenv("PLAID_REQUIRED_IF_SUPPORTED_PRODUCTS"))
+	PlaidWebhookURL = os.Getenv("PLAID_WEBHOOK_URL")
+	PlaidRedirectURI = os.Getenv("PLAID_REDIRECT_URI")
+
+	// Allowlists for per-request Link token overrides, defaulting to the configured values
+	PlaidAllowedProducts = splitList(os.Getenv("PLAID_ALLOWED_PRODUCTS"))
+	if len(PlaidAllowedProducts) == 0 {
+		PlaidAllowedProducts = append(append(append([]string{}, PlaidProducts...), PlaidOptionalProducts...), PlaidRequiredIfSupportedProducts...)
+	}
+	PlaidAllowedWebhookURLs = splitList(os.Getenv("PLAID_ALLOWED_WEBHOOK_URLS"))
+	if len(PlaidAllowedWebhookURLs) == 0 && PlaidWebhookURL != "" {
+		PlaidAllowedWebhookURLs = []string{PlaidWebhookURL}
+	}
+	PlaidAllowedRedirectURIs = splitList(os.Getenv("PLAID_ALLOWED_REDIRECT_URIS"))
+	if len(PlaidAllowedRedirectURIs) == 0 && PlaidRedirectURI != "" {
+		PlaidAllowedRedirectURIs = []string{PlaidRedirectURI}
+	}
+	PlaidAllowedAccountTypes = splitList(getEnvOrDefault("PLAID_ALLOWED_ACCOUNT_TYPES", "depository,credit,loan,investment"))
+
+	// Payment partners processor tokens may be created for
+	PlaidProcessors = splitList(os.Getenv("PLAID_PROCESSORS"))
+
+	// Polling backs up TRANSFER webhooks, zero disables it
+	TransferEventSyncInterval, err = time.ParseDuration(getEnvOrDefault("TRANSFER_EVENT_SYNC_INTERVAL", "5m"))
+	if err != nil {
+		log.Fatal("Invalid TRANSFER_EVENT_SYNC_INTERVAL: ", err)
+	}
+
+	// Debit guard thresholds, a max amount of zero means no limit
+	DebitMinRemainingBalance = getFloatOrDefault("DEBIT_MIN_REMAINING_BALANCE", 0)
+	DebitMaxAmount = getFloatOrDefault("DEBIT_MAX_AMOUNT", 0)
+	DebitRequireAvailableBalance = getEnvOrDefault("DEBIT_REQUIRE_AVAILABLE_BALANCE", "false") == "true"
+
 	if ProjectID == "" || Secret == "" {
 		log.Fatal("Missing required environment variables: STYTCH_PROJECT_ID and/or STYTCH_SECRET")
 	}
@@ -65,3 +145,39 @@ func init() {
 		log.Fatal("ENCRYPTION_KEY must be at least 32 characters long")
 	}
 }
+
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+/*
+getFloatOrDefault parses a numeric environment value, exiting on malformed input
+*/
+func getFloatOrDefault(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+	return parsed
+}
+
+/*
+splitList parses a comma separated environment value, ignoring blanks
+*/
+func splitList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,37 @@
+-- +goose Up
+-- Cursor returned by /transactions/sync, stored per item
+ALTER TABLE link_item ADD COLUMN IF NOT EXISTS transactions_cursor TEXT;
+
+CREATE TABLE IF NOT EXISTS link_transaction (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    transaction_id TEXT NOT NULL UNIQUE,
+    account_id BIGINT NOT NULL REFERENCES link_account(id) ON DELETE CASCADE,
+    item_id BIGINT NOT NULL REFERENCES link_item(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount NUMERIC(19, 4) NOT NULL,
+    iso_currency_code TEXT,
+    unofficial_currency_code TEXT,
+    date DATE NOT NULL,
+    authorized_date DATE,
+    name TEXT,
+    merchant_name TEXT,
+    payment_channel TEXT,
+    category TEXT,
+    category_detailed TEXT,
+    pending BOOLEAN NOT NULL DEFAULT FALSE,
+    pending_transaction_id TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_transaction_account_id ON link_transaction(account_id);
+CREATE INDEX IF NOT EXISTS idx_link_transaction_item_id ON link_transaction(item_id);
+CREATE INDEX IF NOT EXISTS idx_link_transaction_user_id_date ON link_transaction(user_id, date DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_link_transaction_user_id_date;
+DROP INDEX IF EXISTS idx_link_transaction_item_id;
+DROP INDEX IF EXISTS idx_link_transaction_account_id;
+DROP TABLE IF EXISTS link_transaction;
+
+ALTER TABLE link_item DROP COLUMN IF EXISTS transactions_cursor;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Deterministic HMAC blind indexes for encrypted columns, backfilled by the link service on startup
+ALTER TABLE link_item ADD COLUMN IF NOT EXISTS access_token_index TEXT;
+ALTER TABLE link_account ADD COLUMN IF NOT EXISTS account_id_index TEXT;
+
+-- Indexes on the encrypted values can never match a lookup, replace them with the blind indexes
+DROP INDEX IF EXISTS idx_link_item_access_token;
+DROP INDEX IF EXISTS idx_link_account_account_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_link_item_access_token_index ON link_item(access_token_index);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_link_account_account_id_index ON link_account(account_id_index);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_link_account_account_id_index;
+DROP INDEX IF EXISTS idx_link_item_access_token_index;
+
+CREATE INDEX IF NOT EXISTS idx_link_account_account_id ON link_account(account_id);
+CREATE INDEX IF NOT EXISTS idx_link_item_access_token ON link_item(access_token);
+
+ALTER TABLE link_account DROP COLUMN IF EXISTS account_id_index;
+ALTER TABLE link_item DROP COLUMN IF EXISTS access_token_index;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Last error Plaid reported for the item, cleared once the user completes Link update mode
+ALTER TABLE link_item ADD COLUMN IF NOT EXISTS error_code TEXT;
+ALTER TABLE link_item ADD COLUMN IF NOT EXISTS error_message TEXT;
+
+-- +goose Down
+ALTER TABLE link_item DROP COLUMN IF EXISTS error_message;
+ALTER TABLE link_item DROP COLUMN IF EXISTS error_code;
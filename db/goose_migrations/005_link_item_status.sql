@@ -0,0 +1,19 @@
+-- +goose Up
+-- Health of each item, filled from /item/get, Plaid errors and ITEM webhooks
+CREATE TYPE link_item_status AS ENUM ('healthy', 'login_required', 'pending_expiration', 'pending_disconnect', 'error');
+
+ALTER TABLE link_item ADD COLUMN IF NOT EXISTS status link_item_status NOT NULL DEFAULT 'healthy';
+ALTER TABLE link_item ADD COLUMN IF NOT EXISTS consent_expiration_time TIMESTAMPTZ;
+ALTER TABLE link_item ADD COLUMN IF NOT EXISTS last_successful_update TIMESTAMPTZ;
+ALTER TABLE link_item ADD COLUMN IF NOT EXISTS last_failed_update TIMESTAMPTZ;
+
+UPDATE link_item SET status = 'error' WHERE error_code IS NOT NULL;
+UPDATE link_item SET status = 'login_required' WHERE error_code = 'ITEM_LOGIN_REQUIRED';
+
+-- +goose Down
+ALTER TABLE link_item DROP COLUMN IF EXISTS last_failed_update;
+ALTER TABLE link_item DROP COLUMN IF EXISTS last_successful_update;
+ALTER TABLE link_item DROP COLUMN IF EXISTS consent_expiration_time;
+ALTER TABLE link_item DROP COLUMN IF EXISTS status;
+
+DROP TYPE IF EXISTS link_item_status;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Point-in-time balances from /accounts/balance/get (realtime) and /accounts/get (cached)
+CREATE TABLE IF NOT EXISTS account_balance_snapshot (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    account_id BIGINT NOT NULL REFERENCES link_account(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    current_balance NUMERIC(19, 4),
+    available_balance NUMERIC(19, 4),
+    balance_limit NUMERIC(19, 4),
+    iso_currency_code TEXT,
+    unofficial_currency_code TEXT,
+    source TEXT NOT NULL, -- realtime or cached
+    captured_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_balance_snapshot_account_id_captured_at ON account_balance_snapshot(account_id, captured_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_account_balance_snapshot_account_id_captured_at;
+DROP TABLE IF EXISTS account_balance_snapshot;
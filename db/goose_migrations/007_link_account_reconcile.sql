@@ -0,0 +1,12 @@
+-- +goose Up
+-- Lets stored accounts be reconciled with Plaid's account list instead of inserted again
+ALTER TABLE link_account ADD COLUMN IF NOT EXISTS persistent_account_id TEXT;
+ALTER TABLE link_account ADD COLUMN IF NOT EXISTS closed_at TIMESTAMPTZ;
+ALTER TABLE link_account ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE link_account SET updated_at = created_at WHERE updated_at IS NULL;
+
+-- +goose Down
+ALTER TABLE link_account DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE link_account DROP COLUMN IF EXISTS closed_at;
+ALTER TABLE link_account DROP COLUMN IF EXISTS persistent_account_id;
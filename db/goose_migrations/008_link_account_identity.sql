@@ -0,0 +1,14 @@
+-- +goose Up
+-- Account owner data from /identity/get and how well it matches the user's profile
+ALTER TABLE link_account ADD COLUMN IF NOT EXISTS identity_owners TEXT; -- encrypt at rest
+ALTER TABLE link_account ADD COLUMN IF NOT EXISTS identity_name_score INTEGER;
+ALTER TABLE link_account ADD COLUMN IF NOT EXISTS identity_email_score INTEGER;
+ALTER TABLE link_account ADD COLUMN IF NOT EXISTS identity_match_score INTEGER;
+ALTER TABLE link_account ADD COLUMN IF NOT EXISTS identity_updated_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE link_account DROP COLUMN IF EXISTS identity_updated_at;
+ALTER TABLE link_account DROP COLUMN IF EXISTS identity_match_score;
+ALTER TABLE link_account DROP COLUMN IF EXISTS identity_email_score;
+ALTER TABLE link_account DROP COLUMN IF EXISTS identity_name_score;
+ALTER TABLE link_account DROP COLUMN IF EXISTS identity_owners;
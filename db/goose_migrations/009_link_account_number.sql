@@ -0,0 +1,18 @@
+-- +goose Up
+-- ACH and EFT numbers from /auth/get, every number is encrypted at rest
+CREATE TABLE IF NOT EXISTS link_account_number (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    account_id BIGINT NOT NULL UNIQUE REFERENCES link_account(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    network TEXT NOT NULL, -- ach or eft
+    account_number TEXT NOT NULL, -- encrypt at rest
+    routing_number TEXT NOT NULL, -- encrypt at rest, ACH routing or EFT institution number
+    wire_routing_number TEXT, -- encrypt at rest
+    branch_number TEXT, -- encrypt at rest, EFT only
+    is_tokenized BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS link_account_number;
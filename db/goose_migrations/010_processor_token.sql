@@ -0,0 +1,22 @@
+-- +goose Up
+-- Audit of every processor token issued for a linked account
+CREATE TABLE IF NOT EXISTS processor_token (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_id BIGINT REFERENCES link_item(id) ON DELETE SET NULL,
+    account_id BIGINT REFERENCES link_account(id) ON DELETE SET NULL,
+    processor TEXT NOT NULL,
+    processor_token TEXT NOT NULL, -- encrypt at rest
+    request_id TEXT,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_processor_token_user_id ON processor_token(user_id);
+CREATE INDEX IF NOT EXISTS idx_processor_token_item_id ON processor_token(item_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_processor_token_item_id;
+DROP INDEX IF EXISTS idx_processor_token_user_id;
+DROP TABLE IF EXISTS processor_token;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Stripe objects created from a Plaid bank account token for the account
+ALTER TABLE link_account ADD COLUMN IF NOT EXISTS stripe_customer_id TEXT;
+ALTER TABLE link_account ADD COLUMN IF NOT EXISTS stripe_bank_account_id TEXT;
+
+-- +goose Down
+ALTER TABLE link_account DROP COLUMN IF EXISTS stripe_bank_account_id;
+ALTER TABLE link_account DROP COLUMN IF EXISTS stripe_customer_id;
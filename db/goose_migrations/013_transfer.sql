@@ -0,0 +1,54 @@
+-- +goose Up
+-- ACH transfers of linked accounts made through Plaid Transfer
+CREATE TYPE transfer_status AS ENUM ('pending', 'posted', 'settled', 'funds_available', 'cancelled', 'failed', 'returned');
+
+CREATE TABLE IF NOT EXISTS transfer_authorization (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    account_id BIGINT REFERENCES link_account(id) ON DELETE SET NULL,
+    authorization_id TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL,
+    amount NUMERIC(28, 2) NOT NULL,
+    decision TEXT NOT NULL,
+    rationale_code TEXT,
+    rationale_description TEXT,
+    request_id TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfer_authorization_user_id ON transfer_authorization(user_id);
+
+CREATE TABLE IF NOT EXISTS transfer (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    account_id BIGINT REFERENCES link_account(id) ON DELETE SET NULL,
+    authorization_id BIGINT NOT NULL REFERENCES transfer_authorization(id),
+    transfer_id TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL,
+    amount NUMERIC(28, 2) NOT NULL,
+    description TEXT NOT NULL,
+    status transfer_status NOT NULL DEFAULT 'pending',
+    failure_code TEXT,
+    ach_return_code TEXT,
+    failure_description TEXT,
+    last_event_id INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfer_user_id ON transfer(user_id);
+
+-- Last /transfer/event/sync event processed, a single row
+CREATE TABLE IF NOT EXISTS transfer_event_cursor (
+    id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    last_event_id INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS transfer_event_cursor;
+DROP INDEX IF EXISTS idx_transfer_user_id;
+DROP TABLE IF EXISTS transfer;
+DROP INDEX IF EXISTS idx_transfer_authorization_user_id;
+DROP TABLE IF EXISTS transfer_authorization;
+DROP TYPE IF EXISTS transfer_status;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Balance checks run before money is pulled from a linked account
+CREATE TABLE IF NOT EXISTS debit_check (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    account_id BIGINT REFERENCES link_account(id) ON DELETE SET NULL,
+    purpose TEXT NOT NULL,
+    amount NUMERIC(28, 2),
+    available_balance NUMERIC(28, 2),
+    current_balance NUMERIC(28, 2),
+    approved BOOLEAN NOT NULL,
+    reason TEXT,
+    request_id TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_debit_check_account_id ON debit_check(account_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_debit_check_account_id;
+DROP TABLE IF EXISTS debit_check;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- A transfer row is written before Plaid is called, its Plaid ID is filled in afterwards
+ALTER TABLE transfer ALTER COLUMN transfer_id DROP NOT NULL;
+ALTER TABLE transfer ADD CONSTRAINT transfer_authorization_id_key UNIQUE (authorization_id);
+
+-- +goose Down
+ALTER TABLE transfer DROP CONSTRAINT IF EXISTS transfer_authorization_id_key;
+DELETE FROM transfer WHERE transfer_id IS NULL;
+ALTER TABLE transfer ALTER COLUMN transfer_id SET NOT NULL;
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+	"driftGo/api/common/utils"
+	"errors"
+	"time"
+)
+
+var (
+	ErrForbidden            = errors.New("stytch user does not belong to the session")
+	ErrSecondFactorRequired = errors.New("a recent second factor is required")
+)
+
+// How recently a session factor must have been authenticated to count for a sensitive operation
+const StepUpMaxAge = 5 * time.Minute
+
+/*
+authorizeUser checks that the Stytch user ID a request acts on is the user of the authenticated session.
+It must run before any Stytch call that takes a user ID from the request.
+*/
+func authorizeUser(ctx context.Context, userID string) error {
+	sessionUserID := utils.GetStytchUserID(ctx)
+	if sessionUserID == "" || userID != sessionUserID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+/*
+requireSecondFactor checks that the session authenticated a TOTP or recovery code within StepUpMaxAge
+*/
+func requireSecondFactor(ctx context.Context) error {
+	if time.Since(utils.GetSecondFactorAt(ctx)) > StepUpMaxAge {
+		return ErrSecondFactorRequired
+	}
+	return nil
+}
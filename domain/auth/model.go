@@ -15,3 +15,36 @@ type User struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+/*
+PasswordFeedback explains how Stytch rated a password under the project's strength policy
+*/
+type PasswordFeedback struct {
+	Valid          bool          `json:"valid"`
+	Score          int32         `json:"score"`
+	Breached       bool          `json:"breached"`
+	StrengthPolicy string        `json:"strength_policy"`
+	Warning        string        `json:"warning,omitempty"`
+	Suggestions    []string      `json:"suggestions,omitempty"`
+	LUDS           *LUDSFeedback `json:"luds,omitempty"`
+}
+
+/*
+LUDSFeedback lists the character classes a password has and how far it is from the LUDS policy
+*/
+type LUDSFeedback struct {
+	HasLowerCase      bool  `json:"has_lower_case"`
+	HasUpperCase      bool  `json:"has_upper_case"`
+	HasDigit          bool  `json:"has_digit"`
+	HasSymbol         bool  `json:"has_symbol"`
+	MissingComplexity int32 `json:"missing_complexity"`
+	MissingCharacters int32 `json:"missing_characters"`
+}
+
+/*
+OTPSend identifies a sent passcode; the method ID must be passed back when authenticating the code.
+It holds nothing about the user, so the public send endpoints do not reveal whether an account exists.
+*/
+type OTPSend struct {
+	MethodID string `json:"method_id"`
+}
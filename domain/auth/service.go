@@ -2,6 +2,8 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"driftGo/api/common/utils"
 	"driftGo/config"
@@ -10,28 +12,37 @@ import (
 	"github.com/stytchauth/stytch-go/v16/stytch/consumer/magiclinks/email"
 	"github.com/stytchauth/stytch-go/v16/stytch/consumer/oauth"
 	"github.com/stytchauth/stytch-go/v16/stytch/consumer/passwords"
+	passwordEmail "github.com/stytchauth/stytch-go/v16/stytch/consumer/passwords/email"
 	"github.com/stytchauth/stytch-go/v16/stytch/consumer/passwords/session"
 	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
 	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
 	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+	"github.com/stytchauth/stytch-go/v16/stytch/stytcherror"
 )
 
+// How long a password reset link stays valid
+const passwordResetExpirationMinutes = 30
+
 /*
 Service handles all auth-related operations
 */
 type Service struct {
 	client *stytchapi.API
+
+	// How old a session JWT may be before it is checked with Stytch again
+	sessionJWTMaxAge time.Duration
 }
 
 /*
-NewService creates a new auth service
+NewService creates a new auth service.
+The Stytch client fetches and caches the project JWKS, which is used to verify session JWTs locally.
 */
-func NewService(projectID, secret string) (*Service, error) {
+func NewService(projectID, secret string, sessionJWTMaxAge time.Duration) (*Service, error) {
 	client, err := stytchapi.NewClient(projectID, secret)
 	if err != nil {
 		return nil, err
 	}
-	return &Service{client: client}, nil
+	return &Service{client: client, sessionJWTMaxAge: sessionJWTMaxAge}, nil
 }
 
 func (s *Service) SendCreateAccountMagicLink(ctx context.Context, userEmail, codeChallenge string) (*email.LoginOrCreateResponse, error) {
@@ -46,14 +57,67 @@ func (s *Service) SendCreateAccountMagicLink(ctx context.Context, userEmail, cod
 	return s.client.MagicLinks.Email.LoginOrCreate(ctx, params)
 }
 
+/*
+SetPasswordBySession sets the password of the session user.
+A password Stytch rejects as weak or breached is returned as a WeakPasswordError.
+*/
 func (s *Service) SetPasswordBySession(ctx context.Context, password string, sessionDurationMinutes int32) (*session.ResetResponse, error) {
 	params := &session.ResetParams{
 		Password:               password,
 		SessionToken:           utils.GetSessionToken(ctx),
+		SessionJWT:             utils.GetSessionJWT(ctx),
 		SessionDurationMinutes: sessionDurationMinutes,
 	}
 
-	return s.client.Passwords.Sessions.Reset(ctx, params)
+	resp, err := s.client.Passwords.Sessions.Reset(ctx, params)
+	if err != nil {
+		return nil, s.passwordRejection(ctx, password, err)
+	}
+	return resp, nil
+}
+
+/*
+StartPasswordReset emails a password reset link bound to the PKCE code challenge.
+An unknown email is not reported, so the endpoint cannot be used to discover accounts.
+*/
+func (s *Service) StartPasswordReset(ctx context.Context, userEmail, codeChallenge string) error {
+	params := &passwordEmail.ResetStartParams{
+		Email:                          userEmail,
+		CodeChallenge:                  codeChallenge,
+		ResetPasswordRedirectURL:       config.PasswordResetRedirectURL,
+		ResetPasswordExpirationMinutes: passwordResetExpirationMinutes,
+	}
+
+	_, err := s.client.Passwords.Email.ResetStart(ctx, params)
+	var stytchErr stytcherror.Error
+	if errors.As(err, &stytchErr) && (stytchErr.ErrorType == "email_not_found" || stytchErr.ErrorType == "user_not_found") {
+		return nil
+	}
+	return err
+}
+
+/*
+ResetPassword sets a new password from the emailed reset token and starts a session.
+The code verifier must match the challenge the reset was started with.
+A password Stytch rejects as weak or breached is returned as a WeakPasswordError.
+*/
+func (s *Service) ResetPassword(ctx context.Context, token, password, codeVerifier string, sessionDurationMinutes int32) (*passwordEmail.ResetResponse, error) {
+	if sessionDurationMinutes == 0 {
+		sessionDurationMinutes = 60
+	}
+
+	params := &passwordEmail.ResetParams{
+		Token:                  token,
+		Password:               password,
+		CodeVerifier:           codeVerifier,
+		SessionDurationMinutes: sessionDurationMinutes,
+	}
+
+	resp, err := s.client.Passwords.Email.Reset(ctx, params)
+	if err != nil {
+		return nil, s.passwordRejection(ctx, password, err)
+	}
+	return resp, nil
 }
 
 func (s *Service) AuthenticateMagicLink(ctx context.Context, token, codeVerifier string) (*magiclinks.AuthenticateResponse, error) {
@@ -79,12 +143,17 @@ func (s *Service) Login(ctx context.Context, userEmail, password string) (*passw
 func (s *Service) Logout(ctx context.Context) (*sessions.RevokeResponse, error) {
 	params := &sessions.RevokeParams{
 		SessionToken: utils.GetSessionToken(ctx),
+		SessionJWT:   utils.GetSessionJWT(ctx),
 	}
 
 	return s.client.Sessions.Revoke(ctx, params)
 }
 
 func (s *Service) AttachOAuth(ctx context.Context, userID, provider string) (*oauth.AttachResponse, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
 	params := &oauth.AttachParams{
 		UserID:   userID,
 		Provider: provider,
@@ -109,9 +178,23 @@ func (s *Service) AuthenticateSession(ctx context.Context, sessionToken string)
 	return s.client.Sessions.Authenticate(ctx, params)
 }
 
+/*
+AuthenticateSessionJWT verifies a session JWT locally against the cached JWKS while it is younger than the max age.
+Older or unverifiable JWTs are authenticated with Stytch, whose response carries a fresh JWT.
+A locally verified response only has the session, not the user.
+*/
+func (s *Service) AuthenticateSessionJWT(ctx context.Context, sessionJWT string) (*sessions.AuthenticateResponse, error) {
+	params := &sessions.AuthenticateParams{
+		SessionJWT: sessionJWT,
+	}
+
+	return s.client.Sessions.AuthenticateJWT(ctx, s.sessionJWTMaxAge, params)
+}
+
 func (s *Service) ExtendSession(ctx context.Context, sessionDurationMinutes int32) (*sessions.AuthenticateResponse, error) {
 	params := &sessions.AuthenticateParams{
 		SessionToken:           utils.GetSessionToken(ctx),
+		SessionJWT:             utils.GetSessionJWT(ctx),
 		SessionDurationMinutes: sessionDurationMinutes,
 	}
 
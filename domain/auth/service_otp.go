@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp"
+	otpEmail "github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/email"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/sms"
+)
+
+/*
+SendEmailOTP emails a one-time passcode, creating the user if the email is new.
+The returned method ID is the Stytch email ID the code was sent to.
+*/
+func (s *Service) SendEmailOTP(ctx context.Context, userEmail string) (*OTPSend, error) {
+	params := &otpEmail.LoginOrCreateParams{
+		Email: userEmail,
+	}
+
+	resp, err := s.client.OTPs.Email.LoginOrCreate(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OTPSend{MethodID: resp.EmailID}, nil
+}
+
+/*
+SendSMSOTP texts a one-time passcode to an E.164 phone number, creating the user if the number is new.
+The returned method ID is the Stytch phone ID the code was sent to.
+*/
+func (s *Service) SendSMSOTP(ctx context.Context, phoneNumber string) (*OTPSend, error) {
+	params := &sms.LoginOrCreateParams{
+		PhoneNumber: phoneNumber,
+	}
+
+	resp, err := s.client.OTPs.Sms.LoginOrCreate(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OTPSend{MethodID: resp.PhoneID}, nil
+}
+
+/*
+AuthenticateOTP checks a passcode against the method it was sent to and starts a session
+*/
+func (s *Service) AuthenticateOTP(ctx context.Context, methodID, code string, sessionDurationMinutes int32) (*otp.AuthenticateResponse, error) {
+	if sessionDurationMinutes == 0 {
+		sessionDurationMinutes = 60
+	}
+
+	params := &otp.AuthenticateParams{
+		MethodID:               methodID,
+		Code:                   code,
+		SessionDurationMinutes: sessionDurationMinutes,
+	}
+
+	return s.client.OTPs.Authenticate(ctx, params)
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/passwords"
+	"github.com/stytchauth/stytch-go/v16/stytch/stytcherror"
+)
+
+/*
+WeakPasswordError is returned when Stytch rejects a new password, carrying the strength feedback for it
+*/
+type WeakPasswordError struct {
+	Feedback *PasswordFeedback
+}
+
+func (e *WeakPasswordError) Error() string {
+	if e.Feedback.Breached {
+		return "password has appeared in a data breach"
+	}
+	return "password does not meet the strength policy"
+}
+
+/*
+CheckPasswordStrength rates a password without setting it.
+The email is optional and lets zxcvbn penalise passwords built from it.
+*/
+func (s *Service) CheckPasswordStrength(ctx context.Context, userEmail, password string) (*PasswordFeedback, error) {
+	params := &passwords.StrengthCheckParams{
+		Email:    userEmail,
+		Password: password,
+	}
+
+	resp, err := s.client.Passwords.StrengthCheck(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return passwordFeedback(resp), nil
+}
+
+/*
+passwordRejection turns a Stytch weak password failure into a WeakPasswordError with the strength feedback.
+Other errors, and failures of the strength check itself, are returned unchanged.
+*/
+func (s *Service) passwordRejection(ctx context.Context, password string, err error) error {
+	var stytchErr stytcherror.Error
+	if !errors.As(err, &stytchErr) || (stytchErr.ErrorType != "weak_password" && stytchErr.ErrorType != "breached_password") {
+		return err
+	}
+
+	feedback, checkErr := s.CheckPasswordStrength(ctx, "", password)
+	if checkErr != nil {
+		return err
+	}
+	return &WeakPasswordError{Feedback: feedback}
+}
+
+func passwordFeedback(resp *passwords.StrengthCheckResponse) *PasswordFeedback {
+	feedback := &PasswordFeedback{
+		Valid:          resp.ValidPassword,
+		Score:          resp.Score,
+		Breached:       resp.BreachedPassword,
+		StrengthPolicy: resp.StrengthPolicy,
+	}
+	if resp.Feedback == nil {
+		return feedback
+	}
+
+	feedback.Warning = resp.Feedback.Warning
+	feedback.Suggestions = resp.Feedback.Suggestions
+	if luds := resp.Feedback.LudsRequirements; luds != nil {
+		feedback.LUDS = &LUDSFeedback{
+			HasLowerCase:      luds.HasLowerCase,
+			HasUpperCase:      luds.HasUpperCase,
+			HasDigit:          luds.HasDigit,
+			HasSymbol:         luds.HasSymbol,
+			MissingComplexity: luds.MissingComplexity,
+			MissingCharacters: luds.MissingCharacters,
+		}
+	}
+	return feedback
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+
+	"driftGo/api/common/utils"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/totps"
+)
+
+/*
+CreateTOTP starts authenticator app enrollment for the session user.
+The response carries the secret, QR code and recovery codes, which are only shown once.
+Enrollment completes when the first code is authenticated.
+A user who already has a verified authenticator must prove it before enrolling another,
+otherwise a password alone would be enough to add a second factor.
+*/
+func (s *Service) CreateTOTP(ctx context.Context) (*totps.CreateResponse, error) {
+	user, err := s.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, totp := range user.TOTPs {
+		if totp.Verified {
+			if err := requireSecondFactor(ctx); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	params := &totps.CreateParams{
+		UserID: utils.GetStytchUserID(ctx),
+	}
+
+	return s.client.TOTPs.Create(ctx, params)
+}
+
+/*
+AuthenticateTOTP checks an authenticator app code and adds it as a factor of the current session
+*/
+func (s *Service) AuthenticateTOTP(ctx context.Context, code string, sessionDurationMinutes int32) (*totps.AuthenticateResponse, error) {
+	params := &totps.AuthenticateParams{
+		UserID:                 utils.GetStytchUserID(ctx),
+		TOTPCode:               code,
+		SessionToken:           utils.GetSessionToken(ctx),
+		SessionJWT:             utils.GetSessionJWT(ctx),
+		SessionDurationMinutes: sessionDurationMinutes,
+	}
+
+	return s.client.TOTPs.Authenticate(ctx, params)
+}
+
+/*
+GetTOTPRecoveryCodes returns the unused recovery codes of the session user's authenticators
+*/
+func (s *Service) GetTOTPRecoveryCodes(ctx context.Context) (*totps.RecoveryCodesResponse, error) {
+	params := &totps.RecoveryCodesParams{
+		UserID: utils.GetStytchUserID(ctx),
+	}
+
+	return s.client.TOTPs.RecoveryCodes(ctx, params)
+}
+
+/*
+RecoverTOTP uses a recovery code in place of an authenticator app code.
+Each code works once, and it counts as a second factor of the current session.
+*/
+func (s *Service) RecoverTOTP(ctx context.Context, recoveryCode string, sessionDurationMinutes int32) (*totps.RecoverResponse, error) {
+	params := &totps.RecoverParams{
+		UserID:                 utils.GetStytchUserID(ctx),
+		RecoveryCode:           recoveryCode,
+		SessionToken:           utils.GetSessionToken(ctx),
+		SessionJWT:             utils.GetSessionJWT(ctx),
+		SessionDurationMinutes: sessionDurationMinutes,
+	}
+
+	return s.client.TOTPs.Recover(ctx, params)
+}
@@ -0,0 +1,73 @@
+package link
+
+import (
+	"context"
+	"driftGo/api/common/utils"
+	"errors"
+)
+
+/*
+authorizeItem loads a link item and checks that it belongs to the authenticated user.
+It must run before any Plaid call made on behalf of the user for that item.
+*/
+func (s *Service) authorizeItem(ctx context.Context, ID int64) (*LinkItem, error) {
+	userID := utils.GetUserID(ctx)
+	if userID == 0 {
+		return nil, errors.New("user ID not found in context")
+	}
+
+	linkItem, err := s.GetLinkItemByID(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if linkItem.UserID != userID {
+		return nil, ErrLinkForbidden
+	}
+
+	return linkItem, nil
+}
+
+/*
+authorizeAccount loads a link account by its Plaid account ID and checks that it belongs to the authenticated user.
+It must run before any Plaid call made on behalf of the user for that account.
+*/
+func (s *Service) authorizeAccount(ctx context.Context, accountID string) (*LinkAccount, error) {
+	userID := utils.GetUserID(ctx)
+	if userID == 0 {
+		return nil, errors.New("user ID not found in context")
+	}
+
+	linkAccount, err := s.GetLinkAccountByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if linkAccount.UserID != userID {
+		return nil, ErrLinkForbidden
+	}
+
+	return linkAccount, nil
+}
+
+/*
+authorizeLinkAccount loads a link account by its own ID and checks that it belongs to the authenticated user.
+It must run before any Plaid call made on behalf of the user for that account.
+*/
+func (s *Service) authorizeLinkAccount(ctx context.Context, ID int64) (*LinkAccount, error) {
+	userID := utils.GetUserID(ctx)
+	if userID == 0 {
+		return nil, errors.New("user ID not found in context")
+	}
+
+	linkAccount, err := s.GetLinkAccountByID(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if linkAccount.UserID != userID {
+		return nil, ErrLinkForbidden
+	}
+
+	return linkAccount, nil
+}
@@ -0,0 +1,238 @@
+package link
+
+import (
+	"fmt"
+
+	"github.com/plaid/plaid-go/v35/plaid"
+)
+
+/*
+LinkTokenConfig holds the defaults used for every Link token and the allowlists per-request overrides are checked against
+*/
+type LinkTokenConfig struct {
+	ClientName                  string
+	Language                    string
+	CountryCodes                []string
+	Products                    []string
+	OptionalProducts            []string
+	RequiredIfSupportedProducts []string
+	WebhookURL                  string
+	RedirectURI                 string
+
+	AllowedProducts     []string
+	AllowedWebhookURLs  []string
+	AllowedRedirectURIs []string
+	AllowedAccountTypes []string
+}
+
+/*
+LinkTokenOptions are the per-request overrides of LinkTokenConfig.
+Empty fields keep the configured default. AccountFilters maps an account type to the subtypes Link should show.
+*/
+type LinkTokenOptions struct {
+	Products                    []string
+	OptionalProducts            []string
+	RequiredIfSupportedProducts []string
+	AccountFilters              map[string][]string
+	WebhookURL                  string
+	RedirectURI                 string
+}
+
+/*
+LinkTokenOptionError is returned when a Link token option is unknown to Plaid or not on the configured allowlist
+*/
+type LinkTokenOptionError struct {
+	Option string
+	Value  string
+}
+
+func (e *LinkTokenOptionError) Error() string {
+	return fmt.Sprintf("%s %q is not allowed", e.Option, e.Value)
+}
+
+/*
+Account types that can be filtered in Link
+*/
+const (
+	accountTypeDepository = "depository"
+	accountTypeCredit     = "credit"
+	accountTypeLoan       = "loan"
+	accountTypeInvestment = "investment"
+)
+
+/*
+validate checks the configured defaults once at startup so a typo fails fast instead of on the first Link token
+*/
+func (c LinkTokenConfig) validate() error {
+	if c.ClientName == "" || c.Language == "" {
+		return fmt.Errorf("link token client name and language are required")
+	}
+
+	if _, err := toCountryCodes(c.CountryCodes); err != nil {
+		return err
+	}
+
+	for _, products := range [][]string{c.Products, c.OptionalProducts, c.RequiredIfSupportedProducts, c.AllowedProducts} {
+		if _, err := toProducts(products); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+apply sets the products, account filters, webhook and redirect URI of a Link token request,
+taking each from the options when given and from the config otherwise
+*/
+func (c LinkTokenConfig) apply(request *plaid.LinkTokenCreateRequest, options LinkTokenOptions) error {
+	products, err := c.allowedProducts(options.Products, c.Products)
+	if err != nil {
+		return err
+	}
+	request.SetProducts(products)
+
+	optionalProducts, err := c.allowedProducts(options.OptionalProducts, c.OptionalProducts)
+	if err != nil {
+		return err
+	}
+	if len(optionalProducts) > 0 {
+		request.SetOptionalProducts(optionalProducts)
+	}
+
+	requiredIfSupportedProducts, err := c.allowedProducts(options.RequiredIfSupportedProducts, c.RequiredIfSupportedProducts)
+	if err != nil {
+		return err
+	}
+	if len(requiredIfSupportedProducts) > 0 {
+		request.SetRequiredIfSupportedProducts(requiredIfSupportedProducts)
+	}
+
+	if len(options.AccountFilters) > 0 {
+		accountFilters, err := c.accountFilters(options.AccountFilters)
+		if err != nil {
+			return err
+		}
+		request.SetAccountFilters(*accountFilters)
+	}
+
+	if options.WebhookURL != "" {
+		if !contains(c.AllowedWebhookURLs, options.WebhookURL) {
+			return &LinkTokenOptionError{Option: "webhook", Value: options.WebhookURL}
+		}
+		request.SetWebhook(options.WebhookURL)
+	}
+
+	if options.RedirectURI != "" {
+		if !contains(c.AllowedRedirectURIs, options.RedirectURI) {
+			return &LinkTokenOptionError{Option: "redirect_uri", Value: options.RedirectURI}
+		}
+		request.SetRedirectUri(options.RedirectURI)
+	}
+
+	return nil
+}
+
+func (c LinkTokenConfig) allowedProducts(requested, defaults []string) ([]plaid.Products, error) {
+	if len(requested) == 0 {
+		return toProducts(defaults)
+	}
+
+	for _, product := range requested {
+		if !contains(c.AllowedProducts, product) {
+			return nil, &LinkTokenOptionError{Option: "product", Value: product}
+		}
+	}
+
+	return toProducts(requested)
+}
+
+func (c LinkTokenConfig) accountFilters(requested map[string][]string) (*plaid.LinkTokenAccountFilters, error) {
+	accountFilters := plaid.NewLinkTokenAccountFilters()
+
+	for accountType, subtypes := range requested {
+		if !contains(c.AllowedAccountTypes, accountType) {
+			return nil, &LinkTokenOptionError{Option: "account type", Value: accountType}
+		}
+
+		switch accountType {
+		case accountTypeDepository:
+			parsed, err := toSubtypes(subtypes, plaid.NewDepositoryAccountSubtypeFromValue)
+			if err != nil {
+				return nil, err
+			}
+			accountFilters.SetDepository(*plaid.NewDepositoryFilter(parsed))
+
+		case accountTypeCredit:
+			parsed, err := toSubtypes(subtypes, plaid.NewCreditAccountSubtypeFromValue)
+			if err != nil {
+				return nil, err
+			}
+			accountFilters.SetCredit(*plaid.NewCreditFilter(parsed))
+
+		case accountTypeLoan:
+			parsed, err := toSubtypes(subtypes, plaid.NewLoanAccountSubtypeFromValue)
+			if err != nil {
+				return nil, err
+			}
+			accountFilters.SetLoan(*plaid.NewLoanFilter(parsed))
+
+		case accountTypeInvestment:
+			parsed, err := toSubtypes(subtypes, plaid.NewInvestmentAccountSubtypeFromValue)
+			if err != nil {
+				return nil, err
+			}
+			accountFilters.SetInvestment(*plaid.NewInvestmentFilter(parsed))
+
+		default:
+			return nil, &LinkTokenOptionError{Option: "account type", Value: accountType}
+		}
+	}
+
+	return accountFilters, nil
+}
+
+func toProducts(values []string) ([]plaid.Products, error) {
+	products := make([]plaid.Products, 0, len(values))
+	for _, value := range values {
+		product, err := plaid.NewProductsFromValue(value)
+		if err != nil {
+			return nil, &LinkTokenOptionError{Option: "product", Value: value}
+		}
+		products = append(products, *product)
+	}
+	return products, nil
+}
+
+func toCountryCodes(values []string) ([]plaid.CountryCode, error) {
+	countryCodes := make([]plaid.CountryCode, 0, len(values))
+	for _, value := range values {
+		countryCode, err := plaid.NewCountryCodeFromValue(value)
+		if err != nil {
+			return nil, &LinkTokenOptionError{Option: "country code", Value: value}
+		}
+		countryCodes = append(countryCodes, *countryCode)
+	}
+	return countryCodes, nil
+}
+
+func toSubtypes[T any](values []string, parse func(string) (*T, error)) ([]T, error) {
+	subtypes := make([]T, 0, len(values))
+	for _, value := range values {
+		subtype, err := parse(value)
+		if err != nil {
+			return nil, &LinkTokenOptionError{Option: "account subtype", Value: value}
+		}
+		subtypes = append(subtypes, *subtype)
+	}
+	return subtypes, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -17,3 +17,12 @@ type AccessTokenCallResponse struct {
 	AccessToken string `json:"access_token"`
 	ItemID      string `json:"item_id"`
 }
+
+/*
+ItemError is the error Plaid attaches to an item, as sent in ITEM webhooks
+*/
+type ItemError struct {
+	ErrorType    string `json:"error_type"`
+	ErrorCode    string `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+}
@@ -5,6 +5,7 @@ import (
 	"driftGo/api/common/utils"
 	"driftGo/domain/user"
 	"driftGo/pkg/encryption"
+	"driftGo/pkg/stripe"
 	"errors"
 	"strconv"
 
@@ -14,7 +15,18 @@ import (
 )
 
 var (
-	ErrLinkNotFound = errors.New("link not found")
+	ErrLinkNotFound  = errors.New("link not found")
+	ErrLinkForbidden = errors.New("link does not belong to user")
+
+	ErrItemUpdateRequired = errors.New("item still requires an update")
+)
+
+/*
+Plaid error codes returned once an item has already been removed
+*/
+const (
+	errCodeItemNotFound       = "ITEM_NOT_FOUND"
+	errCodeInvalidAccessToken = "INVALID_ACCESS_TOKEN"
 )
 
 /*
@@ -23,14 +35,22 @@ Service handles all Plaid link-related operations
 type Service struct {
 	client      *plaid.APIClient
 	userService user.UserInterface
+	pool        *pgxpool.Pool
+	queries     *Queries
 	database    Querier
 	encryptor   *encryption.Encryptor
+
+	linkTokenConfig LinkTokenConfig
+	processors      []string
+	stripe          *stripe.Client
+
+	debitGuardConfig DebitGuardConfig
 }
 
 /*
 NewService creates a new link service with the provided Plaid credentials and database
 */
-func NewService(clientID, secret, env string, userService user.UserInterface, db *pgxpool.Pool, encryptionKey string) (*Service, error) {
+func NewService(clientID, secret, env string, linkTokenConfig LinkTokenConfig, processors []string, debitGuardConfig DebitGuardConfig, stripeClient *stripe.Client, userService user.UserInterface, db *pgxpool.Pool, encryptionKey string) (*Service, error) {
 	var plaidEnv plaid.Environment
 	switch env {
 	case "sandbox":
@@ -41,6 +61,14 @@ func NewService(clientID, secret, env string, userService user.UserInterface, db
 		return nil, errors.New("Invalid Plaid environment: " + env)
 	}
 
+	if err := linkTokenConfig.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := validateProcessors(processors); err != nil {
+		return nil, err
+	}
+
 	configuration := plaid.NewConfiguration()
 	configuration.AddDefaultHeader("PLAID-CLIENT-ID", clientID)
 	configuration.AddDefaultHeader("PLAID-SECRET", secret)
@@ -54,29 +82,148 @@ func NewService(clientID, secret, env string, userService user.UserInterface, db
 		return nil, err
 	}
 
+	queries := New(db)
+
 	return &Service{
 		client:      client,
 		userService: userService,
-		database:    New(db),
+		pool:        db,
+		queries:     queries,
+		database:    queries,
 		encryptor:   encryptor,
+
+		linkTokenConfig: linkTokenConfig,
+		processors:      processors,
+		stripe:          stripeClient,
+
+		debitGuardConfig: debitGuardConfig,
 	}, nil
 }
 
-func (s *Service) CreateLinkToken(ctx context.Context) (*LinkTokenCallResponse, error) {
+/*
+withTx runs fn inside a single database transaction.
+fn receives a copy of the service whose queries run on the transaction, which is committed only if fn succeeds.
+*/
+func (s *Service) withTx(ctx context.Context, fn func(txService *Service) error) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	txService := *s
+	txService.database = s.queries.WithTx(tx)
+
+	if err := fn(&txService); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+/*
+CreateLinkToken creates a Link token for linking a new item.
+Products, account filters, webhook and redirect URI come from the config unless overridden in options,
+and overrides must be on the configured allowlist.
+*/
+func (s *Service) CreateLinkToken(ctx context.Context, options LinkTokenOptions) (*LinkTokenCallResponse, error) {
+	request, err := s.newLinkTokenRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.linkTokenConfig.apply(request, options); err != nil {
+		return nil, err
+	}
+
+	return s.createLinkToken(ctx, request)
+}
+
+/*
+CreateUpdateLinkToken creates a Link token in update mode for one of the authenticated user's items.
+Update mode repairs the existing item instead of creating a duplicate. With accountSelectionEnabled
+the user can also share accounts they did not select when the item was first linked.
+*/
+func (s *Service) CreateUpdateLinkToken(ctx context.Context, itemID int64, accountSelectionEnabled bool) (*LinkTokenCallResponse, error) {
+	linkItem, err := s.authorizeItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.encryptor.Decrypt(linkItem.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := s.newLinkTokenRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	request.SetAccessToken(accessToken)
+	if accountSelectionEnabled {
+		update := plaid.NewLinkTokenCreateRequestUpdate()
+		update.SetAccountSelectionEnabled(true)
+		request.SetUpdate(*update)
+	}
+
+	return s.createLinkToken(ctx, request)
+}
+
+/*
+CompleteItemUpdate is called after the user finishes Link in update mode.
+It refreshes the item status from Plaid, which clears the stored error once the item is healthy again,
+and reconciles the item's accounts with the ones the user shared.
+*/
+func (s *Service) CompleteItemUpdate(ctx context.Context, itemID int64) error {
+	linkItem, err := s.authorizeItem(ctx, itemID)
+	if err != nil {
+		return err
+	}
+
+	item, err := s.RefreshItemStatus(ctx, linkItem)
+	if err != nil {
+		return err
+	}
+
+	if item.Error.IsSet() && item.Error.Get() != nil {
+		return ErrItemUpdateRequired
+	}
+
+	// The user may have shared new accounts or stopped sharing old ones in update mode
+	return s.refreshItemAccounts(ctx, linkItem)
+}
+
+/*
+newLinkTokenRequest builds a Link token request with the configured client name, language, countries, webhook and redirect URI
+*/
+func (s *Service) newLinkTokenRequest(ctx context.Context) (*plaid.LinkTokenCreateRequest, error) {
 	user := plaid.LinkTokenCreateRequestUser{
 		ClientUserId: strconv.FormatInt(utils.GetUserID(ctx), 10),
 	}
 
+	countryCodes, err := toCountryCodes(s.linkTokenConfig.CountryCodes)
+	if err != nil {
+		return nil, err
+	}
+
 	request := plaid.NewLinkTokenCreateRequest(
-		"drift",
-		"en",
-		// check country code
-		[]plaid.CountryCode{plaid.COUNTRYCODE_US, plaid.COUNTRYCODE_CA},
+		s.linkTokenConfig.ClientName,
+		s.linkTokenConfig.Language,
+		countryCodes,
 		user,
 	)
 
-	request.SetProducts([]plaid.Products{plaid.PRODUCTS_AUTH, plaid.PRODUCTS_IDENTITY})
+	if s.linkTokenConfig.WebhookURL != "" {
+		request.SetWebhook(s.linkTokenConfig.WebhookURL)
+	}
+	if s.linkTokenConfig.RedirectURI != "" {
+		request.SetRedirectUri(s.linkTokenConfig.RedirectURI)
+	}
 
+	return request, nil
+}
+
+func (s *Service) createLinkToken(ctx context.Context, request *plaid.LinkTokenCreateRequest) (*LinkTokenCallResponse, error) {
 	linkToken, _, err := s.client.PlaidApi.LinkTokenCreate(ctx).LinkTokenCreateRequest(*request).Execute()
 	if err != nil {
 		return nil, err
@@ -89,69 +236,128 @@ func (s *Service) CreateLinkToken(ctx context.Context) (*LinkTokenCallResponse,
 	}, nil
 }
 
-func (s *Service) ExchangePublicTokenAndSave(ctx context.Context, publicToken string) error {
+/*
+ExchangePublicTokenAndSave exchanges the public token and stores the new item with its accounts in one database transaction.
+If the user already linked the same accounts, a DuplicateItemError carries the existing item ID.
+If anything fails after the exchange, including a duplicate, the new item is removed at Plaid so no access token is left orphaned.
+*/
+func (s *Service) ExchangePublicTokenAndSave(ctx context.Context, publicToken string) (*LinkItem, error) {
+	userID := utils.GetUserID(ctx)
+	if userID == 0 {
+		return nil, errors.New("user ID not found in context")
+	}
+
 	accessTokenResponse, err := s.exchangePublicToken(ctx, publicToken)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	userID := utils.GetUserID(ctx)
-	if userID == 0 {
-		return errors.New("user ID not found in context")
+	linkItem, err := s.saveExchangedItem(ctx, userID, accessTokenResponse)
+	if err != nil {
+		s.removeOrphanedItem(ctx, accessTokenResponse)
+		return nil, err
 	}
 
-	institutionID, institutionName, err := s.GetInstitutionMetadata(ctx, accessTokenResponse.AccessToken)
+	return linkItem, nil
+}
+
+/*
+UnlinkItem disconnects an item of the authenticated user.
+The item is removed at Plaid first so billing stops, then deleted along with its cascaded accounts.
+*/
+func (s *Service) UnlinkItem(ctx context.Context, ID int64) error {
+	linkItem, err := s.authorizeItem(ctx, ID)
 	if err != nil {
 		return err
 	}
 
-	linkItem, err := s.CreateLinkItem(ctx, userID, accessTokenResponse.AccessToken, accessTokenResponse.ItemID, institutionID, institutionName)
+	accessToken, err := s.encryptor.Decrypt(linkItem.AccessToken)
 	if err != nil {
 		return err
 	}
 
+	if err := s.RemoveItem(ctx, accessToken); err != nil {
+		// A previous attempt may have removed the item at Plaid without deleting our row
+		plaidErr, convErr := plaid.ToPlaidError(err)
+		if convErr != nil || (plaidErr.ErrorCode != errCodeItemNotFound && plaidErr.ErrorCode != errCodeInvalidAccessToken) {
+			return err
+		}
+		log.WithField("link_item_id", linkItem.ID).Warn("Item was already removed at Plaid")
+	}
+
+	return s.DeleteLinkItemByID(ctx, linkItem.ID)
+}
+
+func (s *Service) saveExchangedItem(ctx context.Context, userID int64, accessTokenResponse *AccessTokenCallResponse) (*LinkItem, error) {
+	itemResponse, err := s.getItem(ctx, accessTokenResponse.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	item := itemResponse.GetItem()
+
 	accounts, err := s.GetAccounts(ctx, accessTokenResponse.AccessToken)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = s.SaveAccountsFromPlaid(ctx, accounts, linkItem.ID, userID)
+	duplicate, err := s.findDuplicateItem(ctx, userID, item.GetInstitutionId(), accounts)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if duplicate != nil {
+		log.WithFields(log.Fields{"link_item_id": duplicate.ID, "item_id": accessTokenResponse.ItemID}).Info("Rejecting duplicate item")
+		return nil, &DuplicateItemError{ExistingItemID: duplicate.ID}
 	}
 
-	return nil
-}
+	var linkItem *LinkItem
+	err = s.withTx(ctx, func(txService *Service) error {
+		linkItem, err = txService.CreateLinkItem(ctx, userID, accessTokenResponse.AccessToken, accessTokenResponse.ItemID, item.GetInstitutionId(), item.GetInstitutionName())
+		if err != nil {
+			return err
+		}
 
-func (s *Service) GetAccounts(ctx context.Context, accessToken string) ([]plaid.AccountBase, error) {
-	request := plaid.NewAccountsGetRequest(accessToken)
+		if err := txService.applyItemStatus(ctx, linkItem.ID, &item); err != nil {
+			return err
+		}
 
-	response, _, err := s.client.PlaidApi.AccountsGet(ctx).AccountsGetRequest(*request).Execute()
+		return txService.ReconcileAccounts(ctx, accounts, linkItem.ID, userID)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return response.GetAccounts(), nil
+	return linkItem, nil
 }
 
-func (s *Service) CreateStripeProcessorToken(ctx context.Context, accessToken string, accountID string) (string, error) {
-	decryptedAccountID, err := s.encryptor.Decrypt(accountID)
-	if err != nil {
-		return "", err
+/*
+removeOrphanedItem is the compensating step of ExchangePublicTokenAndSave.
+It runs even if the request context was cancelled, since the access token would otherwise stay live at Plaid.
+*/
+func (s *Service) removeOrphanedItem(ctx context.Context, accessTokenResponse *AccessTokenCallResponse) {
+	if err := s.RemoveItem(context.WithoutCancel(ctx), accessTokenResponse.AccessToken); err != nil {
+		log.WithError(err).WithField("item_id", accessTokenResponse.ItemID).Error("Failed to remove orphaned item at Plaid")
 	}
+}
 
-	request := plaid.NewProcessorStripeBankAccountTokenCreateRequest(accessToken, decryptedAccountID)
-	log.WithField("request", request).Info("Request for stripe processor token")
+/*
+RemoveItem calls Plaid /item/remove, which invalidates the access token and stops billing for the item
+*/
+func (s *Service) RemoveItem(ctx context.Context, accessToken string) error {
+	request := plaid.NewItemRemoveRequest(accessToken)
+
+	_, _, err := s.client.PlaidApi.ItemRemove(ctx).ItemRemoveRequest(*request).Execute()
+	return err
+}
+
+func (s *Service) GetAccounts(ctx context.Context, accessToken string) ([]plaid.AccountBase, error) {
+	request := plaid.NewAccountsGetRequest(accessToken)
 
-	response, _, err := s.client.PlaidApi.ProcessorStripeBankAccountTokenCreate(ctx).ProcessorStripeBankAccountTokenCreateRequest(*request).Execute()
+	response, _, err := s.client.PlaidApi.AccountsGet(ctx).AccountsGetRequest(*request).Execute()
 	if err != nil {
-		if plaidErr, ok := err.(plaid.GenericOpenAPIError); ok {
-			log.Error("Plaid error: ", string(plaidErr.Body()))
-		}
-		return "", err
+		return nil, err
 	}
 
-	return response.GetStripeBankAccountToken(), nil
+	return response.GetAccounts(), nil
 }
 
 func (s *Service) exchangePublicToken(ctx context.Context, publicToken string) (*AccessTokenCallResponse, error) {
@@ -169,37 +375,22 @@ func (s *Service) exchangePublicToken(ctx context.Context, publicToken string) (
 }
 
 func (s *Service) GetInstitutionMetadata(ctx context.Context, accessToken string) (institutionID string, institutionName string, err error) {
-	institutionID, err = s.getInstitutionID(ctx, accessToken)
-	if err != nil {
-		return "", "", err
-	}
-
-	institutionName, err = s.getInstitutionName(ctx, accessToken)
+	response, err := s.getItem(ctx, accessToken)
 	if err != nil {
 		return "", "", err
 	}
 
-	return institutionID, institutionName, nil
+	item := response.GetItem()
+	return item.GetInstitutionId(), item.GetInstitutionName(), nil
 }
 
-func (s *Service) getInstitutionID(ctx context.Context, accessToken string) (institutionId string, err error) {
+func (s *Service) getItem(ctx context.Context, accessToken string) (*plaid.ItemGetResponse, error) {
 	request := plaid.NewItemGetRequest(accessToken)
 
 	response, _, err := s.client.PlaidApi.ItemGet(ctx).ItemGetRequest(*request).Execute()
 	if err != nil {
-		return "", err
-	}
-
-	return *response.GetItem().InstitutionId.Get(), nil
-}
-
-func (s *Service) getInstitutionName(ctx context.Context, accessToken string) (institutionName string, err error) {
-	request := plaid.NewItemGetRequest(accessToken)
-
-	response, _, err := s.client.PlaidApi.ItemGet(ctx).ItemGetRequest(*request).Execute()
-	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return *response.GetItem().InstitutionName.Get(), nil
+	return &response, nil
 }
@@ -0,0 +1,157 @@
+package link
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/plaid/plaid-go/v35/plaid"
+)
+
+/*
+Where a balance snapshot came from
+*/
+const (
+	BalanceSourceRealtime = "realtime"
+	BalanceSourceCached   = "cached"
+)
+
+/*
+RefreshAccountBalance fetches the balance of one of the authenticated user's accounts and stores it as a snapshot.
+Realtime balances come from /accounts/balance/get, which asks the institution directly and is billed per call.
+Otherwise the cached balance from /accounts/get is used.
+*/
+func (s *Service) RefreshAccountBalance(ctx context.Context, ID int64, realtime bool) (*AccountBalanceSnapshot, error) {
+	linkAccount, err := s.authorizeLinkAccount(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.refreshAccountBalance(ctx, linkAccount, realtime)
+}
+
+/*
+GetAccountBalances returns the stored balance snapshots of one of the authenticated user's accounts
+captured in [from, to), oldest first
+*/
+func (s *Service) GetAccountBalances(ctx context.Context, ID int64, from, to time.Time) ([]AccountBalanceSnapshot, error) {
+	linkAccount, err := s.authorizeLinkAccount(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.database.GetAccountBalanceSnapshots(ctx, GetAccountBalanceSnapshotsParams{
+		AccountID: linkAccount.ID,
+		FromTime:  pgtype.Timestamptz{Time: from, Valid: true},
+		ToTime:    pgtype.Timestamptz{Time: to, Valid: true},
+	})
+}
+
+/*
+returns one
+*/
+func (s *Service) CreateAccountBalanceSnapshot(ctx context.Context, accountID, userID int64, balance plaid.AccountBalance, source string) (*AccountBalanceSnapshot, error) {
+	current, err := toNullableNumeric(balance.Current)
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := toNullableNumeric(balance.Available)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := toNullableNumeric(balance.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	isoCurrencyCode := balance.GetIsoCurrencyCode()
+	unofficialCurrencyCode := balance.GetUnofficialCurrencyCode()
+
+	snapshot, err := s.database.CreateAccountBalanceSnapshot(ctx, CreateAccountBalanceSnapshotParams{
+		AccountID:              accountID,
+		UserID:                 userID,
+		CurrentBalance:         current,
+		AvailableBalance:       available,
+		BalanceLimit:           limit,
+		IsoCurrencyCode:        pgtype.Text{String: isoCurrencyCode, Valid: isoCurrencyCode != ""},
+		UnofficialCurrencyCode: pgtype.Text{String: unofficialCurrencyCode, Valid: unofficialCurrencyCode != ""},
+		Source:                 source,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+func (s *Service) refreshAccountBalance(ctx context.Context, linkAccount *LinkAccount, realtime bool) (*AccountBalanceSnapshot, error) {
+	linkItem, err := s.GetLinkItemByID(ctx, linkAccount.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.encryptor.Decrypt(linkItem.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	source := BalanceSourceCached
+	if realtime {
+		source = BalanceSourceRealtime
+	}
+
+	accounts, _, err := s.fetchBalances(ctx, accessToken, linkAccount.AccountID, realtime)
+	if err := s.trackItemCall(ctx, linkItem.ID, err); err != nil {
+		return nil, err
+	}
+
+	for _, account := range accounts {
+		if account.GetAccountId() == linkAccount.AccountID {
+			return s.CreateAccountBalanceSnapshot(ctx, linkAccount.ID, linkAccount.UserID, account.GetBalances(), source)
+		}
+	}
+
+	return nil, ErrLinkNotFound
+}
+
+/*
+fetchBalances asks Plaid for the balance of a single account, either realtime or cached, along with the Plaid request ID
+*/
+func (s *Service) fetchBalances(ctx context.Context, accessToken, accountID string, realtime bool) ([]plaid.AccountBase, string, error) {
+	accountIDs := []string{accountID}
+
+	if realtime {
+		options := plaid.NewAccountsBalanceGetRequestOptions()
+		options.SetAccountIds(accountIDs)
+
+		request := plaid.NewAccountsBalanceGetRequest(accessToken)
+		request.SetOptions(*options)
+
+		response, _, err := s.client.PlaidApi.AccountsBalanceGet(ctx).AccountsBalanceGetRequest(*request).Execute()
+		if err != nil {
+			return nil, "", err
+		}
+		return response.GetAccounts(), response.GetRequestId(), nil
+	}
+
+	options := plaid.NewAccountsGetRequestOptions()
+	options.SetAccountIds(accountIDs)
+
+	request := plaid.NewAccountsGetRequest(accessToken)
+	request.SetOptions(*options)
+
+	response, _, err := s.client.PlaidApi.AccountsGet(ctx).AccountsGetRequest(*request).Execute()
+	if err != nil {
+		return nil, "", err
+	}
+	return response.GetAccounts(), response.GetRequestId(), nil
+}
+
+func toNullableNumeric(value plaid.NullableFloat64) (pgtype.Numeric, error) {
+	if !value.IsSet() || value.Get() == nil {
+		return pgtype.Numeric{}, nil
+	}
+	return toNumeric(*value.Get())
+}
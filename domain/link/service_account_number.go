@@ -0,0 +1,258 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/plaid/plaid-go/v35/plaid"
+)
+
+/*
+Payment networks /auth/get returns numbers for
+*/
+const (
+	NetworkACH = "ach"
+	NetworkEFT = "eft"
+)
+
+// Digits left visible when account and routing numbers are masked
+const maskVisibleDigits = 4
+
+/*
+AccountNumbers are the decrypted ACH or EFT numbers of a link account.
+Unless revealed, every number except its last digits is masked.
+*/
+type AccountNumbers struct {
+	AccountID         int64
+	Network           string
+	AccountNumber     string
+	RoutingNumber     string
+	WireRoutingNumber string
+	BranchNumber      string
+	IsTokenized       bool
+	Masked            bool
+	UpdatedAt         pgtype.Timestamptz
+}
+
+/*
+RefreshItemAuth fetches the ACH and EFT numbers of one of the authenticated user's items from /auth/get
+and stores them encrypted. The returned numbers are masked.
+*/
+func (s *Service) RefreshItemAuth(ctx context.Context, itemID int64) ([]AccountNumbers, error) {
+	linkItem, err := s.authorizeItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	linkAccountNumbers, err := s.syncItemAuth(ctx, linkItem)
+	if err != nil {
+		return nil, err
+	}
+
+	accountNumbers := make([]AccountNumbers, 0, len(linkAccountNumbers))
+	for _, linkAccountNumber := range linkAccountNumbers {
+		numbers, err := s.decryptAccountNumbers(linkAccountNumber, false)
+		if err != nil {
+			return nil, err
+		}
+		accountNumbers = append(accountNumbers, *numbers)
+	}
+
+	return accountNumbers, nil
+}
+
+/*
+GetAccountNumbers returns the stored numbers of one of the authenticated user's accounts.
+Full numbers are only returned with reveal, which callers must gate behind a step-up check.
+*/
+func (s *Service) GetAccountNumbers(ctx context.Context, ID int64, reveal bool) (*AccountNumbers, error) {
+	linkAccount, err := s.authorizeLinkAccount(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	linkAccountNumber, err := s.database.GetLinkAccountNumberByAccountID(ctx, linkAccount.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+
+	return s.decryptAccountNumbers(linkAccountNumber, reveal)
+}
+
+/*
+syncItemAuth calls /auth/get for an item and upserts the numbers of every account we store
+*/
+func (s *Service) syncItemAuth(ctx context.Context, linkItem *LinkItem) ([]LinkAccountNumber, error) {
+	accessToken, err := s.encryptor.Decrypt(linkItem.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	request := plaid.NewAuthGetRequest(accessToken)
+
+	response, _, err := s.client.PlaidApi.AuthGet(ctx).AuthGetRequest(*request).Execute()
+	if err := s.trackItemCall(ctx, linkItem.ID, err); err != nil {
+		return nil, err
+	}
+
+	linkAccounts, err := s.GetLinkAccountsByItemID(ctx, linkItem.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	accountIDs := make(map[string]int64, len(linkAccounts))
+	for _, linkAccount := range linkAccounts {
+		accountIDs[linkAccount.AccountID] = linkAccount.ID
+	}
+
+	numbers := response.GetNumbers()
+	var linkAccountNumbers []LinkAccountNumber
+
+	for _, ach := range numbers.GetAch() {
+		linkAccountID, ok := accountIDs[ach.GetAccountId()]
+		if !ok {
+			continue
+		}
+
+		linkAccountNumber, err := s.upsertLinkAccountNumber(ctx, linkAccountID, linkItem.UserID, NetworkACH, ach.GetAccount(), ach.GetRouting(), ach.GetWireRouting(), "", ach.GetIsTokenizedAccountNumber())
+		if err != nil {
+			return nil, err
+		}
+		linkAccountNumbers = append(linkAccountNumbers, *linkAccountNumber)
+	}
+
+	for _, eft := range numbers.GetEft() {
+		linkAccountID, ok := accountIDs[eft.GetAccountId()]
+		if !ok {
+			continue
+		}
+
+		linkAccountNumber, err := s.upsertLinkAccountNumber(ctx, linkAccountID, linkItem.UserID, NetworkEFT, eft.GetAccount(), eft.GetInstitution(), "", eft.GetBranch(), false)
+		if err != nil {
+			return nil, err
+		}
+		linkAccountNumbers = append(linkAccountNumbers, *linkAccountNumber)
+	}
+
+	return linkAccountNumbers, nil
+}
+
+/*
+returns one
+*/
+func (s *Service) upsertLinkAccountNumber(ctx context.Context, accountID, userID int64, network, accountNumber, routingNumber, wireRoutingNumber, branchNumber string, isTokenized bool) (*LinkAccountNumber, error) {
+	encryptedAccountNumber, err := s.encryptor.Encrypt(accountNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedRoutingNumber, err := s.encryptor.Encrypt(routingNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedWireRoutingNumber, err := s.encryptOptional(wireRoutingNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedBranchNumber, err := s.encryptOptional(branchNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	linkAccountNumber, err := s.database.UpsertLinkAccountNumber(ctx, UpsertLinkAccountNumberParams{
+		AccountID:         accountID,
+		UserID:            userID,
+		Network:           network,
+		AccountNumber:     encryptedAccountNumber,
+		RoutingNumber:     encryptedRoutingNumber,
+		WireRoutingNumber: encryptedWireRoutingNumber,
+		BranchNumber:      encryptedBranchNumber,
+		IsTokenized:       isTokenized,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &linkAccountNumber, nil
+}
+
+func (s *Service) decryptAccountNumbers(linkAccountNumber LinkAccountNumber, reveal bool) (*AccountNumbers, error) {
+	accountNumber, err := s.encryptor.Decrypt(linkAccountNumber.AccountNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	routingNumber, err := s.encryptor.Decrypt(linkAccountNumber.RoutingNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	wireRoutingNumber, err := s.decryptOptional(linkAccountNumber.WireRoutingNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	branchNumber, err := s.decryptOptional(linkAccountNumber.BranchNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	numbers := &AccountNumbers{
+		AccountID:         linkAccountNumber.AccountID,
+		Network:           linkAccountNumber.Network,
+		AccountNumber:     accountNumber,
+		RoutingNumber:     routingNumber,
+		WireRoutingNumber: wireRoutingNumber,
+		BranchNumber:      branchNumber,
+		IsTokenized:       linkAccountNumber.IsTokenized,
+		UpdatedAt:         linkAccountNumber.UpdatedAt,
+	}
+
+	if !reveal {
+		numbers.AccountNumber = maskNumber(numbers.AccountNumber)
+		numbers.RoutingNumber = maskNumber(numbers.RoutingNumber)
+		numbers.WireRoutingNumber = maskNumber(numbers.WireRoutingNumber)
+		numbers.BranchNumber = maskNumber(numbers.BranchNumber)
+		numbers.Masked = true
+	}
+
+	return numbers, nil
+}
+
+func (s *Service) encryptOptional(value string) (pgtype.Text, error) {
+	if value == "" {
+		return pgtype.Text{}, nil
+	}
+
+	encrypted, err := s.encryptor.Encrypt(value)
+	if err != nil {
+		return pgtype.Text{}, err
+	}
+
+	return pgtype.Text{String: encrypted, Valid: true}, nil
+}
+
+func (s *Service) decryptOptional(value pgtype.Text) (string, error) {
+	if !value.Valid {
+		return "", nil
+	}
+	return s.encryptor.Decrypt(value.String)
+}
+
+/*
+maskNumber replaces all but the last digits of a number with asterisks
+*/
+func maskNumber(number string) string {
+	if len(number) <= maskVisibleDigits {
+		return number
+	}
+	return strings.Repeat("*", len(number)-maskVisibleDigits) + number[len(number)-maskVisibleDigits:]
+}
@@ -0,0 +1,203 @@
+package link
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/plaid/plaid-go/v35/plaid"
+)
+
+/*
+accountDetails holds the fields of a Plaid account that are kept in sync with link_account
+*/
+type accountDetails struct {
+	accountID           string
+	persistentAccountID string
+	name                string
+	officialName        string
+	mask                string
+	subtype             string
+	accountType         string
+}
+
+func newAccountDetails(account plaid.AccountBase) accountDetails {
+	details := accountDetails{
+		accountID:           account.GetAccountId(),
+		persistentAccountID: account.GetPersistentAccountId(),
+		name:                account.GetName(),
+		officialName:        account.GetOfficialName(),
+		mask:                account.GetMask(),
+		accountType:         string(account.GetType()),
+	}
+
+	if account.Subtype.IsSet() && account.Subtype.Get() != nil {
+		details.subtype = string(*account.Subtype.Get())
+	}
+
+	return details
+}
+
+/*
+matches reports whether the stored account is open and already holds these details
+*/
+func (d accountDetails) matches(linkAccount LinkAccount) bool {
+	return !linkAccount.ClosedAt.Valid &&
+		linkAccount.AccountID == d.accountID &&
+		linkAccount.PersistentAccountID.String == d.persistentAccountID &&
+		linkAccount.Name.String == d.name &&
+		linkAccount.OfficialName.String == d.officialName &&
+		linkAccount.Mask.String == d.mask &&
+		linkAccount.Subtype.String == d.subtype &&
+		linkAccount.Type.String == d.accountType
+}
+
+/*
+ReconcileAccounts brings the stored accounts of an item in line with the account list Plaid returned.
+Stored accounts are matched by account_id, or by persistent_account_id for tokenized institutions
+that issue a new account_id on every re-link. A persistent_account_id held by another of the user's items
+moves that account, with its transactions, to this item. Matched accounts are updated when they changed,
+new accounts are inserted and stored accounts Plaid no longer returns are marked closed.
+The cached balance of every returned account is recorded as a snapshot.
+*/
+func (s *Service) ReconcileAccounts(ctx context.Context, accounts []plaid.AccountBase, itemID, userID int64) error {
+	linkAccounts, err := s.GetLinkAccountsByItemID(ctx, itemID)
+	if err != nil {
+		return err
+	}
+
+	byAccountID := make(map[string]*LinkAccount, len(linkAccounts))
+	byPersistentAccountID := make(map[string]*LinkAccount, len(linkAccounts))
+	for i := range linkAccounts {
+		byAccountID[linkAccounts[i].AccountID] = &linkAccounts[i]
+		if linkAccounts[i].PersistentAccountID.Valid {
+			byPersistentAccountID[linkAccounts[i].PersistentAccountID.String] = &linkAccounts[i]
+		}
+	}
+
+	// Persistent IDs not found on this item may belong to an account of an earlier item the user re-linked
+	var otherPersistentAccountIDs []string
+	for _, account := range accounts {
+		persistentAccountID := account.GetPersistentAccountId()
+		if _, ok := byAccountID[account.GetAccountId()]; ok || persistentAccountID == "" {
+			continue
+		}
+		if _, ok := byPersistentAccountID[persistentAccountID]; !ok {
+			otherPersistentAccountIDs = append(otherPersistentAccountIDs, persistentAccountID)
+		}
+	}
+	otherItemAccounts, err := s.findAccountsByPersistentID(ctx, userID, otherPersistentAccountIDs)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[int64]bool, len(linkAccounts))
+
+	for _, account := range accounts {
+		details := newAccountDetails(account)
+
+		linkAccount, ok := byAccountID[details.accountID]
+		if !ok && details.persistentAccountID != "" {
+			linkAccount, ok = byPersistentAccountID[details.persistentAccountID]
+			if !ok {
+				if linkAccount, ok = otherItemAccounts[details.persistentAccountID]; ok && !seen[linkAccount.ID] {
+					if err := s.moveLinkAccountToItem(ctx, linkAccount.ID, itemID); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if ok && seen[linkAccount.ID] {
+			ok = false
+		}
+
+		var linkAccountID int64
+		switch {
+		case !ok:
+			created, err := s.CreateLinkAccount(ctx, details.accountID, itemID, userID, details.name, details.officialName, details.mask, details.subtype, details.accountType, details.persistentAccountID)
+			if err != nil {
+				return err
+			}
+			linkAccountID = created.ID
+
+		case !details.matches(*linkAccount):
+			if err := s.UpdateLinkAccount(ctx, linkAccount.ID, details); err != nil {
+				return err
+			}
+			linkAccountID = linkAccount.ID
+
+		default:
+			linkAccountID = linkAccount.ID
+		}
+		seen[linkAccountID] = true
+
+		if _, err := s.CreateAccountBalanceSnapshot(ctx, linkAccountID, userID, account.GetBalances(), BalanceSourceCached); err != nil {
+			return err
+		}
+	}
+
+	for _, linkAccount := range linkAccounts {
+		if seen[linkAccount.ID] || linkAccount.ClosedAt.Valid {
+			continue
+		}
+		if err := s.database.CloseLinkAccount(ctx, linkAccount.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+exec
+*/
+func (s *Service) UpdateLinkAccount(ctx context.Context, ID int64, details accountDetails) error {
+	encryptedAccountID, err := s.encryptor.Encrypt(details.accountID)
+	if err != nil {
+		return err
+	}
+
+	return s.database.UpdateLinkAccount(ctx, UpdateLinkAccountParams{
+		ID:                  ID,
+		AccountID:           encryptedAccountID,
+		AccountIDIndex:      s.blindIndex(details.accountID),
+		Name:                pgtype.Text{String: details.name, Valid: details.name != ""},
+		OfficialName:        pgtype.Text{String: details.officialName, Valid: details.officialName != ""},
+		Mask:                pgtype.Text{String: details.mask, Valid: details.mask != ""},
+		Subtype:             pgtype.Text{String: details.subtype, Valid: details.subtype != ""},
+		Type:                pgtype.Text{String: details.accountType, Valid: details.accountType != ""},
+		PersistentAccountID: pgtype.Text{String: details.persistentAccountID, Valid: details.persistentAccountID != ""},
+	})
+}
+
+/*
+RefreshItemAccounts fetches the current account list of one of the authenticated user's items
+from /accounts/get and reconciles the stored accounts with it
+*/
+func (s *Service) RefreshItemAccounts(ctx context.Context, itemID int64) ([]LinkAccount, error) {
+	linkItem, err := s.authorizeItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshItemAccounts(ctx, linkItem); err != nil {
+		return nil, err
+	}
+
+	return s.GetLinkAccountsByItemID(ctx, linkItem.ID)
+}
+
+func (s *Service) refreshItemAccounts(ctx context.Context, linkItem *LinkItem) error {
+	accessToken, err := s.encryptor.Decrypt(linkItem.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := s.GetAccounts(ctx, accessToken)
+	if err := s.trackItemCall(ctx, linkItem.ID, err); err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(txService *Service) error {
+		return txService.ReconcileAccounts(ctx, accounts, linkItem.ID, linkItem.UserID)
+	})
+}
@@ -0,0 +1,66 @@
+package link
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+blindIndex computes the lookup value stored next to an encrypted column
+*/
+func (s *Service) blindIndex(plaintext string) pgtype.Text {
+	index := s.encryptor.BlindIndex(plaintext)
+	return pgtype.Text{String: index, Valid: index != ""}
+}
+
+/*
+BackfillBlindIndexes computes the blind index of every link_item and link_account row stored before
+the index columns existed. Rows that already have an index are left untouched, so it is safe to run on every start.
+*/
+func (s *Service) BackfillBlindIndexes(ctx context.Context) error {
+	linkItems, err := s.database.GetLinkItemsWithoutIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, linkItem := range linkItems {
+		accessToken, err := s.encryptor.Decrypt(linkItem.AccessToken)
+		if err != nil {
+			return err
+		}
+
+		if err := s.database.SetLinkItemIndex(ctx, SetLinkItemIndexParams{
+			ID:               linkItem.ID,
+			AccessTokenIndex: s.blindIndex(accessToken),
+		}); err != nil {
+			return err
+		}
+	}
+
+	linkAccounts, err := s.database.GetLinkAccountsWithoutIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, linkAccount := range linkAccounts {
+		accountID, err := s.encryptor.Decrypt(linkAccount.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.database.SetLinkAccountIndex(ctx, SetLinkAccountIndexParams{
+			ID:             linkAccount.ID,
+			AccountIDIndex: s.blindIndex(accountID),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if len(linkItems) > 0 || len(linkAccounts) > 0 {
+		log.WithFields(log.Fields{"link_items": len(linkItems), "link_accounts": len(linkAccounts)}).Info("Backfilled blind indexes")
+	}
+
+	return nil
+}
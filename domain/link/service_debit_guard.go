@@ -0,0 +1,189 @@
+package link
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/plaid/plaid-go/v35/plaid"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+What a debit check guards
+*/
+const (
+	DebitPurposeProcessorToken = "processor_token"
+	DebitPurposeTransfer       = "transfer"
+	DebitPurposePayment        = "payment"
+)
+
+/*
+Why a debit was declined
+*/
+const (
+	DebitReasonBalanceUnavailable = "balance_unavailable"
+	DebitReasonInsufficientFunds  = "insufficient_funds"
+	DebitReasonAmountOverLimit    = "amount_over_limit"
+	DebitReasonCurrencyMismatch   = "currency_mismatch"
+)
+
+// ACH debits through Plaid Transfer, Stripe and the processors are all in US dollars
+const debitCurrency = "USD"
+
+/*
+DebitGuardConfig holds the thresholds a debit must pass.
+MinRemainingBalance is what must be left in the account after the debit,
+MaxAmount caps a single debit when set, and RequireAvailableBalance declines
+institutions that only report a current balance instead of falling back to it.
+*/
+type DebitGuardConfig struct {
+	MinRemainingBalance     float64
+	MaxAmount               float64
+	RequireAvailableBalance bool
+}
+
+/*
+DebitDeclinedError is returned when a debit does not pass the guard
+*/
+type DebitDeclinedError struct {
+	CheckID int64
+	Reason  string
+}
+
+func (e *DebitDeclinedError) Error() string {
+	return fmt.Sprintf("debit declined: %s", e.Reason)
+}
+
+/*
+CheckDebit runs the debit guard for one of the authenticated user's accounts.
+It is exported for the payment domain, link flows call checkDebit with the account they already authorized.
+*/
+func (s *Service) CheckDebit(ctx context.Context, accountID int64, amount int64, currency, purpose string) (*DebitCheck, error) {
+	linkAccount, err := s.authorizeLinkAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.checkDebit(ctx, linkAccount, amount, currency, purpose)
+}
+
+/*
+checkDebit fetches a realtime balance from /accounts/balance/get and applies the configured thresholds.
+Every decision is recorded with the Plaid request ID, and a declined debit returns a *DebitDeclinedError.
+The amount is in minor units of currency, and an amount of zero checks only the remaining balance, for flows such as processor tokens where the amount is not known yet.
+*/
+func (s *Service) checkDebit(ctx context.Context, linkAccount *LinkAccount, amount int64, currency, purpose string) (*DebitCheck, error) {
+	linkItem, err := s.GetLinkItemByID(ctx, linkAccount.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.encryptor.Decrypt(linkItem.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, requestID, err := s.fetchBalances(ctx, accessToken, linkAccount.AccountID, true)
+	if err := s.trackItemCall(ctx, linkItem.ID, err); err != nil {
+		return nil, err
+	}
+
+	var balance *plaid.AccountBalance
+	for _, account := range accounts {
+		if account.GetAccountId() == linkAccount.AccountID {
+			accountBalance := account.GetBalances()
+			balance = &accountBalance
+			break
+		}
+	}
+	if balance == nil {
+		return nil, ErrLinkNotFound
+	}
+
+	if _, err := s.CreateAccountBalanceSnapshot(ctx, linkAccount.ID, linkAccount.UserID, *balance, BalanceSourceRealtime); err != nil {
+		return nil, err
+	}
+
+	reason := evaluateDebit(s.debitGuardConfig, *balance, amount, currency)
+
+	check, err := s.recordDebitCheck(ctx, linkAccount, purpose, amount, *balance, reason, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if reason != "" {
+		log.WithFields(log.Fields{"account_id": linkAccount.ID, "purpose": purpose, "reason": reason}).Warn("Debit declined by balance check")
+		return check, &DebitDeclinedError{CheckID: check.ID, Reason: reason}
+	}
+
+	return check, nil
+}
+
+/*
+evaluateDebit applies the guard thresholds to a balance and returns the decline reason, or an empty string when the debit may go ahead.
+Thresholds and balances are in major units, so the amount in minor units is converted only for the comparison.
+A balance in another currency, or with only an unofficial currency code, cannot be compared and is declined.
+*/
+func evaluateDebit(config DebitGuardConfig, balance plaid.AccountBalance, amount int64, currency string) string {
+	if !strings.EqualFold(balance.GetIsoCurrencyCode(), currency) {
+		return DebitReasonCurrencyMismatch
+	}
+
+	debit := float64(amount) / 100
+	if config.MaxAmount > 0 && debit > config.MaxAmount {
+		return DebitReasonAmountOverLimit
+	}
+
+	funds, ok := balance.GetAvailableOk()
+	if (!ok || funds == nil) && !config.RequireAvailableBalance {
+		funds, ok = balance.GetCurrentOk()
+	}
+	if !ok || funds == nil {
+		return DebitReasonBalanceUnavailable
+	}
+
+	if *funds-debit < config.MinRemainingBalance {
+		return DebitReasonInsufficientFunds
+	}
+
+	return ""
+}
+
+/*
+returns one
+*/
+func (s *Service) recordDebitCheck(ctx context.Context, linkAccount *LinkAccount, purpose string, amount int64, balance plaid.AccountBalance, reason, requestID string) (*DebitCheck, error) {
+	var numericAmount pgtype.Numeric
+	if amount > 0 {
+		numericAmount = minorUnitsToNumeric(amount)
+	}
+
+	available, err := toNullableNumeric(balance.Available)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := toNullableNumeric(balance.Current)
+	if err != nil {
+		return nil, err
+	}
+
+	check, err := s.database.CreateDebitCheck(ctx, CreateDebitCheckParams{
+		UserID:           linkAccount.UserID,
+		AccountID:        pgtype.Int8{Int64: linkAccount.ID, Valid: true},
+		Purpose:          purpose,
+		Amount:           numericAmount,
+		AvailableBalance: available,
+		CurrentBalance:   current,
+		Approved:         reason == "",
+		Reason:           pgtype.Text{String: reason, Valid: reason != ""},
+		RequestID:        pgtype.Text{String: requestID, Valid: requestID != ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &check, nil
+}
@@ -0,0 +1,54 @@
+package link
+
+import (
+	"testing"
+
+	"github.com/plaid/plaid-go/v35/plaid"
+)
+
+func newBalance(available, current *float64) plaid.AccountBalance {
+	balance := plaid.NewAccountBalanceWithDefaults()
+	balance.Available = *plaid.NewNullableFloat64(available)
+	balance.Current = *plaid.NewNullableFloat64(current)
+	balance.SetIsoCurrencyCode("USD")
+	return *balance
+}
+
+func newCADBalance(available float64) plaid.AccountBalance {
+	balance := newBalance(&available, nil)
+	balance.SetIsoCurrencyCode("CAD")
+	return balance
+}
+
+func float64Ptr(value float64) *float64 {
+	return &value
+}
+
+func TestEvaluateDebit(t *testing.T) {
+	config := DebitGuardConfig{MinRemainingBalance: 25, MaxAmount: 1000}
+
+	tests := []struct {
+		name    string
+		config  DebitGuardConfig
+		balance plaid.AccountBalance
+		amount  int64
+		reason  string
+	}{
+		{"approved", config, newBalance(float64Ptr(500), float64Ptr(600)), 10000, ""},
+		{"leaves too little", config, newBalance(float64Ptr(120), float64Ptr(600)), 10000, DebitReasonInsufficientFunds},
+		{"over limit", config, newBalance(float64Ptr(5000), nil), 150000, DebitReasonAmountOverLimit},
+		{"falls back to current", config, newBalance(nil, float64Ptr(300)), 10000, ""},
+		{"requires available", DebitGuardConfig{RequireAvailableBalance: true}, newBalance(nil, float64Ptr(300)), 10000, DebitReasonBalanceUnavailable},
+		{"no balance", config, newBalance(nil, nil), 0, DebitReasonBalanceUnavailable},
+		{"balance only check", config, newBalance(float64Ptr(20), nil), 0, DebitReasonInsufficientFunds},
+		{"other currency", config, newCADBalance(500), 10000, DebitReasonCurrencyMismatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if reason := evaluateDebit(test.config, test.balance, test.amount, debitCurrency); reason != test.reason {
+				t.Fatalf("Expected reason %q, got %q", test.reason, reason)
+			}
+		})
+	}
+}
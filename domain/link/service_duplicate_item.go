@@ -0,0 +1,92 @@
+package link
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/plaid/plaid-go/v35/plaid"
+)
+
+/*
+DuplicateItemError is returned when the user links accounts they already linked through another item
+*/
+type DuplicateItemError struct {
+	ExistingItemID int64
+}
+
+func (e *DuplicateItemError) Error() string {
+	return fmt.Sprintf("item is already linked as item %d", e.ExistingItemID)
+}
+
+/*
+findDuplicateItem looks for an item the user already linked at the same institution that holds any of the new accounts.
+Plaid issues new item and account IDs on every link, so accounts are matched by persistent_account_id
+where the institution provides one, and by mask and name otherwise.
+It returns nil when the new item is not a duplicate.
+*/
+func (s *Service) findDuplicateItem(ctx context.Context, userID int64, institutionID string, accounts []plaid.AccountBase) (*LinkItem, error) {
+	if institutionID == "" {
+		return nil, nil
+	}
+
+	var persistentAccountIDs []string
+	for _, account := range accounts {
+		if persistentAccountID := account.GetPersistentAccountId(); persistentAccountID != "" {
+			persistentAccountIDs = append(persistentAccountIDs, persistentAccountID)
+		}
+	}
+	byPersistentAccountID, err := s.findAccountsByPersistentID(ctx, userID, persistentAccountIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, linkAccount := range byPersistentAccountID {
+		linkItem, err := s.database.GetLinkItemByID(ctx, linkAccount.ItemID)
+		if err != nil {
+			return nil, err
+		}
+		if linkItem.InstitutionID.String == institutionID {
+			return &linkItem, nil
+		}
+	}
+
+	linkItems, err := s.database.GetLinkItemsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range linkItems {
+		if linkItems[i].InstitutionID.String != institutionID {
+			continue
+		}
+
+		linkAccounts, err := s.database.GetLinkAccountsByItemID(ctx, linkItems[i].ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if hasMatchingAccount(linkAccounts, accounts) {
+			return &linkItems[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+/*
+hasMatchingAccount reports whether any open stored account has the same mask and name as one of the new accounts
+*/
+func hasMatchingAccount(linkAccounts []LinkAccount, accounts []plaid.AccountBase) bool {
+	for _, linkAccount := range linkAccounts {
+		if linkAccount.ClosedAt.Valid || !linkAccount.Mask.Valid {
+			continue
+		}
+
+		for _, account := range accounts {
+			if account.GetMask() == linkAccount.Mask.String && account.GetName() == linkAccount.Name.String {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,255 @@
+package link
+
+import (
+	"context"
+	"driftGo/domain/user"
+	"encoding/json"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/plaid/plaid-go/v35/plaid"
+)
+
+/*
+Weights of the name and email scores in the overall identity match score.
+Institutions often keep outdated emails, so the name counts for more.
+*/
+const (
+	identityNameWeight  = 0.7
+	identityEmailWeight = 0.3
+)
+
+/*
+IdentityOwner is one account holder as reported by /identity/get
+*/
+type IdentityOwner struct {
+	Names        []string `json:"names"`
+	Emails       []string `json:"emails"`
+	PhoneNumbers []string `json:"phone_numbers"`
+	Addresses    []string `json:"addresses"`
+}
+
+/*
+AccountIdentity is the decrypted identity of a link account with its match against the user's profile.
+Scores run from 0 to 100 and are not valid when there was nothing to compare.
+*/
+type AccountIdentity struct {
+	AccountID  int64
+	Owners     []IdentityOwner
+	NameScore  pgtype.Int4
+	EmailScore pgtype.Int4
+	MatchScore pgtype.Int4
+	UpdatedAt  pgtype.Timestamptz
+}
+
+/*
+RefreshItemIdentity fetches the account owners of one of the authenticated user's items from /identity/get,
+stores them encrypted on each account and scores them against the user's first name, last name and email
+*/
+func (s *Service) RefreshItemIdentity(ctx context.Context, itemID int64) ([]LinkAccount, error) {
+	linkItem, err := s.authorizeItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.encryptor.Decrypt(linkItem.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := s.userService.GetUserByID(ctx, linkItem.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	request := plaid.NewIdentityGetRequest(accessToken)
+
+	response, _, err := s.client.PlaidApi.IdentityGet(ctx).IdentityGetRequest(*request).Execute()
+	if err := s.trackItemCall(ctx, linkItem.ID, err); err != nil {
+		return nil, err
+	}
+
+	linkAccounts, err := s.GetLinkAccountsByItemID(ctx, linkItem.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	accountIDs := make(map[string]int64, len(linkAccounts))
+	for _, linkAccount := range linkAccounts {
+		accountIDs[linkAccount.AccountID] = linkAccount.ID
+	}
+
+	for _, account := range response.GetAccounts() {
+		linkAccountID, ok := accountIDs[account.GetAccountId()]
+		if !ok {
+			continue
+		}
+
+		if err := s.saveAccountIdentity(ctx, linkAccountID, newIdentityOwners(account.GetOwners()), profile); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetLinkAccountsByItemID(ctx, linkItem.ID)
+}
+
+/*
+GetAccountIdentity returns the stored identity of one of the authenticated user's accounts
+*/
+func (s *Service) GetAccountIdentity(ctx context.Context, ID int64) (*AccountIdentity, error) {
+	linkAccount, err := s.authorizeLinkAccount(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !linkAccount.IdentityOwners.Valid {
+		return nil, ErrLinkNotFound
+	}
+
+	decryptedOwners, err := s.encryptor.Decrypt(linkAccount.IdentityOwners.String)
+	if err != nil {
+		return nil, err
+	}
+
+	var owners []IdentityOwner
+	if err := json.Unmarshal([]byte(decryptedOwners), &owners); err != nil {
+		return nil, err
+	}
+
+	return &AccountIdentity{
+		AccountID:  linkAccount.ID,
+		Owners:     owners,
+		NameScore:  linkAccount.IdentityNameScore,
+		EmailScore: linkAccount.IdentityEmailScore,
+		MatchScore: linkAccount.IdentityMatchScore,
+		UpdatedAt:  linkAccount.IdentityUpdatedAt,
+	}, nil
+}
+
+func (s *Service) saveAccountIdentity(ctx context.Context, linkAccountID int64, owners []IdentityOwner, profile *user.User) error {
+	encodedOwners, err := json.Marshal(owners)
+	if err != nil {
+		return err
+	}
+
+	encryptedOwners, err := s.encryptor.Encrypt(string(encodedOwners))
+	if err != nil {
+		return err
+	}
+
+	nameScore, emailScore, matchScore := scoreIdentity(owners, profile)
+
+	return s.database.UpdateLinkAccountIdentity(ctx, UpdateLinkAccountIdentityParams{
+		ID:                 linkAccountID,
+		IdentityOwners:     pgtype.Text{String: encryptedOwners, Valid: true},
+		IdentityNameScore:  nameScore,
+		IdentityEmailScore: emailScore,
+		IdentityMatchScore: matchScore,
+	})
+}
+
+func newIdentityOwners(owners []plaid.Owner) []IdentityOwner {
+	identityOwners := make([]IdentityOwner, 0, len(owners))
+
+	for _, owner := range owners {
+		identityOwner := IdentityOwner{Names: owner.GetNames()}
+
+		for _, email := range owner.GetEmails() {
+			identityOwner.Emails = append(identityOwner.Emails, email.GetData())
+		}
+		for _, phoneNumber := range owner.GetPhoneNumbers() {
+			identityOwner.PhoneNumbers = append(identityOwner.PhoneNumbers, phoneNumber.GetData())
+		}
+		for _, address := range owner.GetAddresses() {
+			data := address.GetData()
+			parts := []string{data.GetStreet(), data.GetCity(), data.GetRegion(), data.GetPostalCode(), data.GetCountry()}
+			identityOwner.Addresses = append(identityOwner.Addresses, joinNonEmpty(parts, ", "))
+		}
+
+		identityOwners = append(identityOwners, identityOwner)
+	}
+
+	return identityOwners
+}
+
+/*
+scoreIdentity compares the account owners with the user's profile.
+The name score is the share of the user's name tokens found in the best matching owner name,
+so middle names and initials reported by the institution do not lower it.
+The email score is 100 when any owner email equals the user's email and 0 otherwise.
+*/
+func scoreIdentity(owners []IdentityOwner, profile *user.User) (nameScore, emailScore, matchScore pgtype.Int4) {
+	userNameTokens := nameTokens(profile.FirstName.String + " " + profile.LastName.String)
+
+	var ownerEmails []string
+	for _, owner := range owners {
+		ownerEmails = append(ownerEmails, owner.Emails...)
+
+		if len(userNameTokens) == 0 {
+			continue
+		}
+		for _, name := range owner.Names {
+			score := tokenOverlap(userNameTokens, nameTokens(name))
+			if !nameScore.Valid || score > nameScore.Int32 {
+				nameScore = pgtype.Int4{Int32: score, Valid: true}
+			}
+		}
+	}
+
+	if len(ownerEmails) > 0 && profile.Email != "" {
+		emailScore = pgtype.Int4{Int32: 0, Valid: true}
+		for _, email := range ownerEmails {
+			if strings.EqualFold(strings.TrimSpace(email), strings.TrimSpace(profile.Email)) {
+				emailScore.Int32 = 100
+				break
+			}
+		}
+	}
+
+	switch {
+	case nameScore.Valid && emailScore.Valid:
+		matchScore = pgtype.Int4{Int32: int32(math.Round(identityNameWeight*float64(nameScore.Int32) + identityEmailWeight*float64(emailScore.Int32))), Valid: true}
+	case nameScore.Valid:
+		matchScore = nameScore
+	case emailScore.Valid:
+		matchScore = emailScore
+	}
+
+	return nameScore, emailScore, matchScore
+}
+
+/*
+nameTokens lowercases a name and splits it into words, dropping punctuation
+*/
+func nameTokens(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+func tokenOverlap(expected, actual []string) int32 {
+	if len(expected) == 0 {
+		return 0
+	}
+
+	found := 0
+	for _, token := range expected {
+		if contains(actual, token) {
+			found++
+		}
+	}
+
+	return int32(math.Round(100 * float64(found) / float64(len(expected))))
+}
+
+func joinNonEmpty(parts []string, separator string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, separator)
+}
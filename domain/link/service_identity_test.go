@@ -0,0 +1,64 @@
+package link
+
+import (
+	"driftGo/domain/user"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestScoreIdentity(t *testing.T) {
+	profile := &user.User{
+		FirstName: pgtype.Text{String: "Jane", Valid: true},
+		LastName:  pgtype.Text{String: "Doe", Valid: true},
+		Email:     "jane@example.com",
+	}
+
+	tests := []struct {
+		name       string
+		owners     []IdentityOwner
+		nameScore  int32
+		emailScore int32
+		matchScore int32
+	}{
+		{
+			name:       "full match with middle initial",
+			owners:     []IdentityOwner{{Names: []string{"JANE A. DOE"}, Emails: []string{"Jane@Example.com"}}},
+			nameScore:  100,
+			emailScore: 100,
+			matchScore: 100,
+		},
+		{
+			name:       "name matches, email differs",
+			owners:     []IdentityOwner{{Names: []string{"Jane Doe"}, Emails: []string{"old@example.com"}}},
+			nameScore:  100,
+			emailScore: 0,
+			matchScore: 70,
+		},
+		{
+			name:       "best of joint owners",
+			owners:     []IdentityOwner{{Names: []string{"John Smith"}}, {Names: []string{"Jane Smith"}, Emails: []string{"jane@example.com"}}},
+			nameScore:  50,
+			emailScore: 100,
+			matchScore: 65,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nameScore, emailScore, matchScore := scoreIdentity(tt.owners, profile)
+			if nameScore.Int32 != tt.nameScore || emailScore.Int32 != tt.emailScore || matchScore.Int32 != tt.matchScore {
+				t.Fatalf("Expected scores %d/%d/%d, got %d/%d/%d", tt.nameScore, tt.emailScore, tt.matchScore, nameScore.Int32, emailScore.Int32, matchScore.Int32)
+			}
+		})
+	}
+}
+
+func TestScoreIdentityWithoutEmails(t *testing.T) {
+	profile := &user.User{Email: "jane@example.com"}
+
+	nameScore, emailScore, matchScore := scoreIdentity([]IdentityOwner{{Names: []string{"Jane Doe"}}}, profile)
+	if nameScore.Valid || emailScore.Valid || matchScore.Valid {
+		t.Fatalf("Expected no scores without a profile name or owner emails, got %v/%v/%v", nameScore, emailScore, matchScore)
+	}
+}
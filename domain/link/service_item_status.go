@@ -0,0 +1,112 @@
+package link
+
+import (
+	"context"
+	"time"
+
+	"github.com/plaid/plaid-go/v35/plaid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	errCodeItemLoginRequired = "ITEM_LOGIN_REQUIRED"
+
+	// Plaid sends PENDING_EXPIRATION seven days before consent runs out
+	consentExpirationWarning = 7 * 24 * time.Hour
+)
+
+/*
+itemStatusForError maps the error code Plaid reports for an item to the stored item status
+*/
+func itemStatusForError(errorCode string) LinkItemStatus {
+	if errorCode == errCodeItemLoginRequired {
+		return LinkItemStatusLoginRequired
+	}
+	return LinkItemStatusError
+}
+
+/*
+trackItemCall records the outcome of a Plaid call made with an item's access token and returns err unchanged.
+Successful calls stamp last_successful_update. Failed calls stamp last_failed_update, and item errors also set the status.
+Tracking failures are only logged so they never hide the result of the Plaid call itself.
+*/
+func (s *Service) trackItemCall(ctx context.Context, linkItemID int64, err error) error {
+	logger := log.WithField("link_item_id", linkItemID)
+
+	if err == nil {
+		if trackErr := s.database.MarkLinkItemUpdateSucceeded(ctx, linkItemID); trackErr != nil {
+			logger.WithError(trackErr).Error("Failed to record successful item update")
+		}
+		return nil
+	}
+
+	plaidErr, convErr := plaid.ToPlaidError(err)
+	if convErr != nil {
+		return err
+	}
+
+	if trackErr := s.database.MarkLinkItemUpdateFailed(ctx, linkItemID); trackErr != nil {
+		logger.WithError(trackErr).Error("Failed to record failed item update")
+	}
+
+	if plaidErr.ErrorType == plaid.PLAIDERRORTYPE_ITEM_ERROR {
+		itemError := &ItemError{
+			ErrorType:    string(plaidErr.ErrorType),
+			ErrorCode:    plaidErr.ErrorCode,
+			ErrorMessage: plaidErr.ErrorMessage,
+		}
+		if trackErr := s.UpdateLinkItemStatus(ctx, linkItemID, itemStatusForError(plaidErr.ErrorCode), itemError); trackErr != nil {
+			logger.WithError(trackErr).Error("Failed to record item error")
+		}
+	}
+
+	return err
+}
+
+/*
+RefreshItemStatus asks Plaid /item/get for the current state of the item and stores its status, error and consent expiration
+*/
+func (s *Service) RefreshItemStatus(ctx context.Context, linkItem *LinkItem) (*plaid.ItemWithConsentFields, error) {
+	accessToken, err := s.encryptor.Decrypt(linkItem.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.getItem(ctx, accessToken)
+	if err := s.trackItemCall(ctx, linkItem.ID, err); err != nil {
+		return nil, err
+	}
+
+	item := response.GetItem()
+	if err := s.applyItemStatus(ctx, linkItem.ID, &item); err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+/*
+applyItemStatus stores the status, error and consent expiration reported by /item/get
+*/
+func (s *Service) applyItemStatus(ctx context.Context, linkItemID int64, item *plaid.ItemWithConsentFields) error {
+	status := LinkItemStatusHealthy
+	var itemError *ItemError
+
+	if item.Error.IsSet() && item.Error.Get() != nil {
+		plaidErr := item.Error.Get()
+		itemError = &ItemError{
+			ErrorType:    string(plaidErr.GetErrorType()),
+			ErrorCode:    plaidErr.GetErrorCode(),
+			ErrorMessage: plaidErr.GetErrorMessage(),
+		}
+		status = itemStatusForError(itemError.ErrorCode)
+	} else if consentExpiration := item.ConsentExpirationTime.Get(); consentExpiration != nil && time.Until(*consentExpiration) < consentExpirationWarning {
+		status = LinkItemStatusPendingExpiration
+	}
+
+	if err := s.UpdateLinkItemStatus(ctx, linkItemID, status, itemError); err != nil {
+		return err
+	}
+
+	return s.UpdateLinkItemConsentExpiration(ctx, linkItemID, item.ConsentExpirationTime.Get())
+}
@@ -7,27 +7,28 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/plaid/plaid-go/v35/plaid"
 )
 
 /*
 returns one
 */
-func (s *Service) CreateLinkAccount(ctx context.Context, accountID string, itemID, userID int64, name, officialName, mask, subtype, accountType string) (*LinkAccount, error) {
+func (s *Service) CreateLinkAccount(ctx context.Context, accountID string, itemID, userID int64, name, officialName, mask, subtype, accountType, persistentAccountID string) (*LinkAccount, error) {
 	encryptedAccountID, err := s.encryptor.Encrypt(accountID)
 	if err != nil {
 		return nil, err
 	}
 
 	params := CreateLinkAccountParams{
-		AccountID:    encryptedAccountID,
-		ItemID:       itemID,
-		UserID:       userID,
-		Name:         pgtype.Text{String: name, Valid: name != ""},
-		OfficialName: pgtype.Text{String: officialName, Valid: officialName != ""},
-		Mask:         pgtype.Text{String: mask, Valid: mask != ""},
-		Subtype:      pgtype.Text{String: subtype, Valid: subtype != ""},
-		Type:         pgtype.Text{String: accountType, Valid: accountType != ""},
+		AccountID:           encryptedAccountID,
+		AccountIDIndex:      s.blindIndex(accountID),
+		ItemID:              itemID,
+		UserID:              userID,
+		Name:                pgtype.Text{String: name, Valid: name != ""},
+		OfficialName:        pgtype.Text{String: officialName, Valid: officialName != ""},
+		Mask:                pgtype.Text{String: mask, Valid: mask != ""},
+		Subtype:             pgtype.Text{String: subtype, Valid: subtype != ""},
+		Type:                pgtype.Text{String: accountType, Valid: accountType != ""},
+		PersistentAccountID: pgtype.Text{String: persistentAccountID, Valid: persistentAccountID != ""},
 	}
 
 	linkAccount, err := s.database.CreateLinkAccount(ctx, params)
@@ -69,13 +70,8 @@ func (s *Service) GetLinkAccountByID(ctx context.Context, ID int64) (*LinkAccoun
 returns one
 */
 func (s *Service) GetLinkAccountByAccountID(ctx context.Context, accountID string) (*LinkAccount, error) {
-	// Encrypt the search parameter since the database stores encrypted values
-	encryptedAccountID, err := s.encryptor.Encrypt(accountID)
-	if err != nil {
-		return nil, err
-	}
-
-	linkAccount, err := s.database.GetLinkAccountByAccountID(ctx, encryptedAccountID)
+	// Encrypted values use a random nonce, so look the account up by its blind index
+	linkAccount, err := s.database.GetLinkAccountByAccountID(ctx, s.blindIndex(accountID))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrLinkNotFound
@@ -112,6 +108,62 @@ func (s *Service) GetLinkAccountsByItemID(ctx context.Context, itemID int64) ([]
 	return linkAccounts, nil
 }
 
+/*
+findAccountsByPersistentID returns the user's open accounts, across all items, that hold any of the persistent account IDs.
+Tokenized institutions issue a new item and account_id on every re-link, the persistent ID is what stays the same.
+*/
+func (s *Service) findAccountsByPersistentID(ctx context.Context, userID int64, persistentAccountIDs []string) (map[string]*LinkAccount, error) {
+	byPersistentAccountID := make(map[string]*LinkAccount)
+	if len(persistentAccountIDs) == 0 {
+		return byPersistentAccountID, nil
+	}
+
+	linkAccounts, err := s.database.GetOpenLinkAccountsByPersistentAccountIDs(ctx, GetOpenLinkAccountsByPersistentAccountIDsParams{
+		UserID:               userID,
+		PersistentAccountIds: persistentAccountIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range linkAccounts {
+		decryptedAccountID, err := s.encryptor.Decrypt(linkAccounts[i].AccountID)
+		if err != nil {
+			return nil, err
+		}
+		linkAccounts[i].AccountID = decryptedAccountID
+
+		// Most recently updated first, so the first account seen wins
+		if _, ok := byPersistentAccountID[linkAccounts[i].PersistentAccountID.String]; !ok {
+			byPersistentAccountID[linkAccounts[i].PersistentAccountID.String] = &linkAccounts[i]
+		}
+	}
+
+	return byPersistentAccountID, nil
+}
+
+/*
+exec
+*/
+func (s *Service) moveLinkAccountToItem(ctx context.Context, ID, itemID int64) error {
+	if err := s.database.MoveLinkAccountToItem(ctx, MoveLinkAccountToItemParams{ID: ID, ItemID: itemID}); err != nil {
+		return err
+	}
+	return s.database.MoveLinkTransactionsToItem(ctx, MoveLinkTransactionsToItemParams{AccountID: ID, ItemID: itemID})
+}
+
+/*
+returns many
+*/
+func (s *Service) GetLinkAccountsByUserItem(ctx context.Context, itemID int64) ([]LinkAccount, error) {
+	linkItem, err := s.authorizeItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetLinkAccountsByItemID(ctx, linkItem.ID)
+}
+
 /*
 returns many
 */
@@ -148,12 +200,7 @@ func (s *Service) DeleteLinkAccount(ctx context.Context, id int64) error {
 exec
 */
 func (s *Service) DeleteLinkAccountByAccountID(ctx context.Context, accountID string) error {
-	encryptedAccountID, err := s.encryptor.Encrypt(accountID)
-	if err != nil {
-		return err
-	}
-
-	return s.database.DeleteLinkAccountByAccountID(ctx, encryptedAccountID)
+	return s.database.DeleteLinkAccountByAccountID(ctx, s.blindIndex(accountID))
 }
 
 /*
@@ -162,36 +209,3 @@ exec
 func (s *Service) DeleteLinkAccountsByItemID(ctx context.Context, itemID int64) error {
 	return s.database.DeleteLinkAccountsByItemID(ctx, itemID)
 }
-
-/*
-exec
-*/
-func (s *Service) SaveAccountsFromPlaid(ctx context.Context, accounts []plaid.AccountBase, itemID, userID int64) error {
-	for _, account := range accounts {
-		name := account.GetName()
-
-		officialName := ""
-		if account.OfficialName.IsSet() {
-			officialName = *account.OfficialName.Get()
-		}
-
-		mask := ""
-		if account.Mask.IsSet() {
-			mask = *account.Mask.Get()
-		}
-
-		subtype := ""
-		if account.Subtype.IsSet() {
-			subtype = string(*account.Subtype.Get())
-		}
-
-		accountType := string(account.GetType())
-
-		_, err := s.CreateLinkAccount(ctx, account.GetAccountId(), itemID, userID, name, officialName, mask, subtype, accountType)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
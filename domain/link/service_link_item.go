@@ -4,6 +4,7 @@ import (
 	"context"
 	"driftGo/api/common/utils"
 	"errors"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -19,11 +20,12 @@ func (s *Service) CreateLinkItem(ctx context.Context, userID int64, accessToken,
 	}
 
 	params := CreateLinkItemParams{
-		UserID:          userID,
-		AccessToken:     encryptedAccessToken,
-		ItemID:          itemID,
-		InstitutionID:   pgtype.Text{String: institutionID, Valid: institutionID != ""},
-		InstitutionName: pgtype.Text{String: institutionName, Valid: institutionName != ""},
+		UserID:           userID,
+		AccessToken:      encryptedAccessToken,
+		AccessTokenIndex: s.blindIndex(accessToken),
+		ItemID:           itemID,
+		InstitutionID:    pgtype.Text{String: institutionID, Valid: institutionID != ""},
+		InstitutionName:  pgtype.Text{String: institutionName, Valid: institutionName != ""},
 	}
 
 	linkItem, err := s.database.CreateLinkItem(ctx, params)
@@ -99,8 +101,11 @@ func (s *Service) GetLinkItemsByUser(ctx context.Context) ([]LinkItem, error) {
 returns one
 */
 func (s *Service) GetAccessTokenByAccountID(ctx context.Context, accountID string) (string, error) {
-	accessToken, err := s.database.GetAccessTokenByAccountID(ctx, accountID)
+	accessToken, err := s.database.GetAccessTokenByAccountID(ctx, s.blindIndex(accountID))
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrLinkNotFound
+		}
 		return "", err
 	}
 
@@ -115,8 +120,40 @@ func (s *Service) GetAccessTokenByAccountID(ctx context.Context, accountID strin
 /*
 exec
 */
+func (s *Service) UpdateLinkItemStatus(ctx context.Context, ID int64, status LinkItemStatus, itemError *ItemError) error {
+	params := UpdateLinkItemStatusParams{ID: ID, Status: status}
+	if itemError != nil {
+		params.ErrorCode = pgtype.Text{String: itemError.ErrorCode, Valid: itemError.ErrorCode != ""}
+		params.ErrorMessage = pgtype.Text{String: itemError.ErrorMessage, Valid: itemError.ErrorMessage != ""}
+	}
+
+	return s.database.UpdateLinkItemStatus(ctx, params)
+}
+
+/*
+exec
+*/
+func (s *Service) UpdateLinkItemConsentExpiration(ctx context.Context, ID int64, consentExpirationTime *time.Time) error {
+	params := UpdateLinkItemConsentExpirationParams{ID: ID}
+	if consentExpirationTime != nil {
+		params.ConsentExpirationTime = pgtype.Timestamptz{Time: *consentExpirationTime, Valid: true}
+	}
+
+	return s.database.UpdateLinkItemConsentExpiration(ctx, params)
+}
+
+/*
+DeleteLinkItemByID deletes an item with its cascaded accounts.
+Plaid invalidates every processor token of a removed item, so their audit records are marked revoked first.
+*/
 func (s *Service) DeleteLinkItemByID(ctx context.Context, ID int64) error {
-	return s.database.DeleteLinkItem(ctx, ID)
+	return s.withTx(ctx, func(txService *Service) error {
+		if err := txService.database.RevokeProcessorTokensByItemID(ctx, pgtype.Int8{Int64: ID, Valid: true}); err != nil {
+			return err
+		}
+
+		return txService.database.DeleteLinkItem(ctx, ID)
+	})
 }
 
 /*
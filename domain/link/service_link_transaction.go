@@ -0,0 +1,274 @@
+package link
+
+import (
+	"context"
+	"driftGo/api/common/utils"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/plaid/plaid-go/v35/plaid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	transactionsSyncPageSize = 500
+	plaidDateLayout          = "2006-01-02"
+
+	// How many times a sync window is restarted after a mutation before giving up until the next sync
+	maxSyncPaginationRestarts = 3
+
+	errCodeSyncMutationDuringPagination = "TRANSACTIONS_SYNC_MUTATION_DURING_PAGINATION"
+)
+
+var ErrUnknownSyncAccount = errors.New("transaction belongs to an unknown account")
+
+/*
+transactionUpdates holds every change returned by /transactions/sync for one cursor window
+*/
+type transactionUpdates struct {
+	added      []plaid.Transaction
+	modified   []plaid.Transaction
+	removed    []plaid.RemovedTransaction
+	nextCursor string
+}
+
+/*
+SyncTransactions pulls every change since the item's stored cursor from /transactions/sync,
+persists added, modified and removed transactions and stores the new cursor on the item.
+*/
+func (s *Service) SyncTransactions(ctx context.Context, linkItemID int64) error {
+	linkItem, err := s.GetLinkItemByID(ctx, linkItemID)
+	if err != nil {
+		return err
+	}
+
+	accessToken, err := s.encryptor.Decrypt(linkItem.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	accountIDs, err := s.itemAccountIDs(ctx, linkItem.ID)
+	if err != nil {
+		return err
+	}
+
+	updates, err := s.fetchTransactionUpdates(ctx, accessToken, linkItem.TransactionsCursor.String)
+	if err := s.trackItemCall(ctx, linkItem.ID, err); err != nil {
+		return err
+	}
+
+	transactions := append(updates.added, updates.modified...)
+
+	// The item gained an account that was not reconciled yet, refresh its accounts before saving anything
+	if hasUnknownAccount(transactions, accountIDs) {
+		log.WithField("link_item_id", linkItem.ID).Info("Refreshing accounts for transactions on an unknown account")
+		if err := s.refreshItemAccounts(ctx, linkItem); err != nil {
+			return err
+		}
+
+		accountIDs, err = s.itemAccountIDs(ctx, linkItem.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, transaction := range transactions {
+		accountID, ok := accountIDs[transaction.GetAccountId()]
+		if !ok {
+			// The cursor is not advanced so Plaid sends the transaction again on the next sync
+			return fmt.Errorf("%w: transaction %s is for unknown account %s", ErrUnknownSyncAccount, transaction.GetTransactionId(), transaction.GetAccountId())
+		}
+
+		if _, err := s.upsertLinkTransaction(ctx, transaction, accountID, linkItem.ID, linkItem.UserID); err != nil {
+			return err
+		}
+	}
+
+	for _, removed := range updates.removed {
+		if err := s.database.DeleteLinkTransactionByTransactionID(ctx, removed.GetTransactionId()); err != nil {
+			return err
+		}
+	}
+
+	return s.database.UpdateLinkItemTransactionsCursor(ctx, UpdateLinkItemTransactionsCursorParams{
+		ID:                 linkItem.ID,
+		TransactionsCursor: pgtype.Text{String: updates.nextCursor, Valid: updates.nextCursor != ""},
+	})
+}
+
+/*
+itemAccountIDs maps Plaid's account IDs to the item's link_account rows
+*/
+func (s *Service) itemAccountIDs(ctx context.Context, linkItemID int64) (map[string]int64, error) {
+	linkAccounts, err := s.GetLinkAccountsByItemID(ctx, linkItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	accountIDs := make(map[string]int64, len(linkAccounts))
+	for _, linkAccount := range linkAccounts {
+		accountIDs[linkAccount.AccountID] = linkAccount.ID
+	}
+
+	return accountIDs, nil
+}
+
+func hasUnknownAccount(transactions []plaid.Transaction, accountIDs map[string]int64) bool {
+	for _, transaction := range transactions {
+		if _, ok := accountIDs[transaction.GetAccountId()]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+SyncTransactionsForUser runs SyncTransactions for every item the authenticated user has linked
+*/
+func (s *Service) SyncTransactionsForUser(ctx context.Context) error {
+	linkItems, err := s.GetLinkItemsByUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, linkItem := range linkItems {
+		if err := s.SyncTransactions(ctx, linkItem.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+returns many
+*/
+func (s *Service) GetLinkTransactionsByUser(ctx context.Context, limit, offset int32) ([]LinkTransaction, error) {
+	userID := utils.GetUserID(ctx)
+	if userID == 0 {
+		return nil, errors.New("user ID not found in context")
+	}
+
+	return s.database.GetLinkTransactionsByUserID(ctx, GetLinkTransactionsByUserIDParams{
+		UserID: userID,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+/*
+fetchTransactionUpdates pages through /transactions/sync until has_more is false.
+If Plaid reports a mutation during pagination the whole window is restarted from the original cursor,
+at most maxSyncPaginationRestarts times, after which the error is returned.
+*/
+func (s *Service) fetchTransactionUpdates(ctx context.Context, accessToken, cursor string) (*transactionUpdates, error) {
+	updates := &transactionUpdates{nextCursor: cursor}
+	restarts := 0
+
+	for hasMore := true; hasMore; {
+		request := plaid.NewTransactionsSyncRequest(accessToken)
+		request.SetCount(transactionsSyncPageSize)
+		if updates.nextCursor != "" {
+			request.SetCursor(updates.nextCursor)
+		}
+
+		response, _, err := s.client.PlaidApi.TransactionsSync(ctx).TransactionsSyncRequest(*request).Execute()
+		if err != nil {
+			if plaidErr, convErr := plaid.ToPlaidError(err); convErr == nil && plaidErr.ErrorCode == errCodeSyncMutationDuringPagination && restarts < maxSyncPaginationRestarts {
+				restarts++
+				updates = &transactionUpdates{nextCursor: cursor}
+				continue
+			}
+			return nil, err
+		}
+
+		updates.added = append(updates.added, response.GetAdded()...)
+		updates.modified = append(updates.modified, response.GetModified()...)
+		updates.removed = append(updates.removed, response.GetRemoved()...)
+		updates.nextCursor = response.GetNextCursor()
+		hasMore = response.GetHasMore()
+	}
+
+	return updates, nil
+}
+
+/*
+returns one
+*/
+func (s *Service) upsertLinkTransaction(ctx context.Context, transaction plaid.Transaction, accountID, itemID, userID int64) (*LinkTransaction, error) {
+	amount, err := toNumeric(transaction.GetAmount())
+	if err != nil {
+		return nil, err
+	}
+
+	category := ""
+	categoryDetailed := ""
+	if transaction.PersonalFinanceCategory.IsSet() && transaction.PersonalFinanceCategory.Get() != nil {
+		category = transaction.PersonalFinanceCategory.Get().GetPrimary()
+		categoryDetailed = transaction.PersonalFinanceCategory.Get().GetDetailed()
+	}
+
+	isoCurrencyCode := transaction.GetIsoCurrencyCode()
+	unofficialCurrencyCode := transaction.GetUnofficialCurrencyCode()
+	name := transaction.GetName()
+	merchantName := transaction.GetMerchantName()
+	paymentChannel := transaction.GetPaymentChannel()
+	pendingTransactionID := transaction.GetPendingTransactionId()
+
+	params := UpsertLinkTransactionParams{
+		TransactionID:          transaction.GetTransactionId(),
+		AccountID:              accountID,
+		ItemID:                 itemID,
+		UserID:                 userID,
+		Amount:                 amount,
+		IsoCurrencyCode:        pgtype.Text{String: isoCurrencyCode, Valid: isoCurrencyCode != ""},
+		UnofficialCurrencyCode: pgtype.Text{String: unofficialCurrencyCode, Valid: unofficialCurrencyCode != ""},
+		Date:                   toDate(transaction.GetDate()),
+		AuthorizedDate:         toDate(transaction.GetAuthorizedDate()),
+		Name:                   pgtype.Text{String: name, Valid: name != ""},
+		MerchantName:           pgtype.Text{String: merchantName, Valid: merchantName != ""},
+		PaymentChannel:         pgtype.Text{String: paymentChannel, Valid: paymentChannel != ""},
+		Category:               pgtype.Text{String: category, Valid: category != ""},
+		CategoryDetailed:       pgtype.Text{String: categoryDetailed, Valid: categoryDetailed != ""},
+		Pending:                transaction.GetPending(),
+		PendingTransactionID:   pgtype.Text{String: pendingTransactionID, Valid: pendingTransactionID != ""},
+	}
+
+	linkTransaction, err := s.database.UpsertLinkTransaction(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &linkTransaction, nil
+}
+
+func toNumeric(value float64) (pgtype.Numeric, error) {
+	var numeric pgtype.Numeric
+	if err := numeric.Scan(strconv.FormatFloat(value, 'f', -1, 64)); err != nil {
+		return pgtype.Numeric{}, err
+	}
+	return numeric, nil
+}
+
+/*
+formatMinorUnits formats an amount in minor units as a decimal string with two places, as Plaid expects amounts
+*/
+func formatMinorUnits(amount int64) string {
+	return fmt.Sprintf("%d.%02d", amount/100, amount%100)
+}
+
+func minorUnitsToNumeric(amount int64) pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(amount), Exp: -2, Valid: true}
+}
+
+func toDate(value string) pgtype.Date {
+	parsed, err := time.Parse(plaidDateLayout, value)
+	if err != nil {
+		return pgtype.Date{}
+	}
+	return pgtype.Date{Time: parsed, Valid: true}
+}
@@ -0,0 +1,239 @@
+package link
+
+import (
+	"context"
+	"driftGo/api/common/utils"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/plaid/plaid-go/v35/plaid"
+)
+
+var ErrProcessorNotAllowed = errors.New("processor is not allowed")
+
+// Stripe has its own Plaid endpoint but is recorded in the same audit table
+const ProcessorStripe = "stripe"
+
+/*
+knownProcessors are the partners /processor/token/create accepts that we may enable through config
+*/
+var knownProcessors = []string{
+	"achq",
+	"adyen",
+	"alpaca",
+	"apex_clearing",
+	"astra",
+	"checkbook",
+	"checkout",
+	"circle",
+	"drivewealth",
+	"dwolla",
+	"finix",
+	"galileo",
+	"gusto",
+	"highnote",
+	"lithic",
+	"marqeta",
+	"modern_treasury",
+	"moov",
+	"ocrolus",
+	"rize",
+	"sila_money",
+	"solid",
+	"treasury_prime",
+	"unit",
+	"vesta",
+	"vopay",
+	"wepay",
+}
+
+/*
+validateProcessors checks at startup that every configured processor is one Plaid knows
+*/
+func validateProcessors(processors []string) error {
+	for _, processor := range processors {
+		if !contains(knownProcessors, processor) {
+			return fmt.Errorf("unknown Plaid processor: %s", processor)
+		}
+	}
+	return nil
+}
+
+/*
+CreateProcessorToken creates a processor token for one of the authenticated user's accounts through /processor/token/create.
+The processor must be enabled in config and the account must pass the debit guard.
+Every token issued is recorded so it can be audited and revoked.
+*/
+func (s *Service) CreateProcessorToken(ctx context.Context, accountID int64, processor string) (*ProcessorToken, string, error) {
+	if !contains(s.processors, processor) {
+		return nil, "", ErrProcessorNotAllowed
+	}
+
+	linkAccount, err := s.authorizeLinkAccount(ctx, accountID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The processor can pull funds once it has the token, so the account must pass the debit guard first
+	if _, err := s.checkDebit(ctx, linkAccount, 0, debitCurrency, DebitPurposeProcessorToken); err != nil {
+		return nil, "", err
+	}
+
+	linkItem, err := s.GetLinkItemByID(ctx, linkAccount.ItemID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	accessToken, err := s.encryptor.Decrypt(linkItem.AccessToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	request := plaid.NewProcessorTokenCreateRequest(accessToken, linkAccount.AccountID, processor)
+
+	response, _, err := s.client.PlaidApi.ProcessorTokenCreate(ctx).ProcessorTokenCreateRequest(*request).Execute()
+	if err := s.trackItemCall(ctx, linkItem.ID, err); err != nil {
+		return nil, "", err
+	}
+
+	processorToken, err := s.recordProcessorToken(ctx, linkAccount, processor, response.GetProcessorToken(), response.GetRequestId())
+	if err != nil {
+		return nil, "", err
+	}
+
+	return processorToken, response.GetProcessorToken(), nil
+}
+
+/*
+SetProcessorTokenPermissions narrows the products a processor token of the authenticated user can access
+through /processor/token/permissions/set. Plaid treats an empty list as every product, so at least one is required.
+*/
+func (s *Service) SetProcessorTokenPermissions(ctx context.Context, ID int64, products []string) error {
+	processorToken, err := s.authorizeProcessorToken(ctx, ID)
+	if err != nil {
+		return err
+	}
+
+	if processorToken.RevokedAt.Valid {
+		return ErrLinkNotFound
+	}
+
+	plaidProducts, err := toProducts(products)
+	if err != nil || len(plaidProducts) == 0 {
+		return &LinkTokenOptionError{Option: "products", Value: fmt.Sprint(products)}
+	}
+
+	decryptedToken, err := s.encryptor.Decrypt(processorToken.ProcessorToken)
+	if err != nil {
+		return err
+	}
+
+	request := plaid.NewProcessorTokenPermissionsSetRequest(decryptedToken, plaidProducts)
+
+	_, _, err = s.client.PlaidApi.ProcessorTokenPermissionsSet(ctx).ProcessorTokenPermissionsSetRequest(*request).Execute()
+	if processorToken.ItemID.Valid {
+		return s.trackItemCall(ctx, processorToken.ItemID.Int64, err)
+	}
+	return err
+}
+
+/*
+ProcessorTokenItemUnlink describes an item removed through one of its processor tokens
+*/
+type ProcessorTokenItemUnlink struct {
+	ItemID            int64
+	ProcessorTokenIDs []int64
+}
+
+/*
+UnlinkProcessorTokenItem removes the item a processor token of the authenticated user was issued for.
+Plaid cannot invalidate a single processor token and an empty permission list grants every product,
+so the only way to cut a partner off is to unlink the whole item. That deletes the item with its accounts,
+transactions and balances, and revokes every processor token of the item, whose IDs are returned.
+*/
+func (s *Service) UnlinkProcessorTokenItem(ctx context.Context, ID int64) (*ProcessorTokenItemUnlink, error) {
+	processorToken, err := s.authorizeProcessorToken(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if processorToken.RevokedAt.Valid || !processorToken.ItemID.Valid {
+		return nil, ErrLinkNotFound
+	}
+
+	processorTokenIDs, err := s.database.GetActiveProcessorTokenIDsByItemID(ctx, processorToken.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.UnlinkItem(ctx, processorToken.ItemID.Int64); err != nil {
+		return nil, err
+	}
+
+	return &ProcessorTokenItemUnlink{
+		ItemID:            processorToken.ItemID.Int64,
+		ProcessorTokenIDs: processorTokenIDs,
+	}, nil
+}
+
+/*
+returns many
+*/
+func (s *Service) GetProcessorTokensByUser(ctx context.Context) ([]ProcessorToken, error) {
+	userID := utils.GetUserID(ctx)
+	if userID == 0 {
+		return nil, errors.New("user ID not found in context")
+	}
+
+	return s.database.GetProcessorTokensByUserID(ctx, userID)
+}
+
+/*
+recordProcessorToken stores the audit record of a processor token, keeping the token encrypted for later permission changes
+*/
+func (s *Service) recordProcessorToken(ctx context.Context, linkAccount *LinkAccount, processor, token, requestID string) (*ProcessorToken, error) {
+	encryptedToken, err := s.encryptor.Encrypt(token)
+	if err != nil {
+		return nil, err
+	}
+
+	processorToken, err := s.database.CreateProcessorToken(ctx, CreateProcessorTokenParams{
+		UserID:         linkAccount.UserID,
+		ItemID:         pgtype.Int8{Int64: linkAccount.ItemID, Valid: true},
+		AccountID:      pgtype.Int8{Int64: linkAccount.ID, Valid: true},
+		Processor:      processor,
+		ProcessorToken: encryptedToken,
+		RequestID:      pgtype.Text{String: requestID, Valid: requestID != ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &processorToken, nil
+}
+
+/*
+authorizeProcessorToken loads a processor token record and checks that it belongs to the authenticated user
+*/
+func (s *Service) authorizeProcessorToken(ctx context.Context, ID int64) (*ProcessorToken, error) {
+	userID := utils.GetUserID(ctx)
+	if userID == 0 {
+		return nil, errors.New("user ID not found in context")
+	}
+
+	processorToken, err := s.database.GetProcessorTokenByID(ctx, ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+
+	if processorToken.UserID != userID {
+		return nil, ErrLinkForbidden
+	}
+
+	return &processorToken, nil
+}
@@ -0,0 +1,160 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/plaid/plaid-go/v35/plaid"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	ErrStripeNotConfigured      = errors.New("stripe is not configured")
+	ErrStripeBankAccountMissing = errors.New("account is not attached to stripe")
+)
+
+/*
+StripeBankAccount is a Stripe bank account token created by Plaid and, once attached, the Stripe objects it produced
+*/
+type StripeBankAccount struct {
+	AccountID           int64
+	Token               string
+	StripeCustomerID    string
+	StripeBankAccountID string
+}
+
+/*
+CreateStripeProcessorToken creates a Stripe bank account token for one of the authenticated user's accounts.
+The account is checked for ownership before its access token is resolved.
+When attach is set the token is attached to the user's Stripe Customer, created on first use, and the Stripe IDs are stored on the account.
+*/
+func (s *Service) CreateStripeProcessorToken(ctx context.Context, accountID string, attach bool) (*StripeBankAccount, error) {
+	if attach && s.stripe == nil {
+		return nil, ErrStripeNotConfigured
+	}
+
+	linkAccount, err := s.authorizeAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.checkDebit(ctx, linkAccount, 0, debitCurrency, DebitPurposeProcessorToken); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.GetAccessTokenByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	request := plaid.NewProcessorStripeBankAccountTokenCreateRequest(accessToken, accountID)
+
+	response, _, err := s.client.PlaidApi.ProcessorStripeBankAccountTokenCreate(ctx).ProcessorStripeBankAccountTokenCreateRequest(*request).Execute()
+	if err := s.trackItemCall(ctx, linkAccount.ItemID, err); err != nil {
+		if plaidErr, ok := err.(plaid.GenericOpenAPIError); ok {
+			log.Error("Plaid error: ", string(plaidErr.Body()))
+		}
+		return nil, err
+	}
+
+	token := response.GetStripeBankAccountToken()
+	if _, err := s.recordProcessorToken(ctx, linkAccount, ProcessorStripe, token, response.GetRequestId()); err != nil {
+		return nil, err
+	}
+
+	bankAccount := &StripeBankAccount{
+		AccountID:           linkAccount.ID,
+		Token:               token,
+		StripeCustomerID:    linkAccount.StripeCustomerID.String,
+		StripeBankAccountID: linkAccount.StripeBankAccountID.String,
+	}
+
+	if !attach {
+		return bankAccount, nil
+	}
+
+	if err := s.attachStripeBankAccount(ctx, linkAccount, bankAccount); err != nil {
+		return nil, err
+	}
+
+	return bankAccount, nil
+}
+
+/*
+attachStripeBankAccount attaches the token as a bank account source on the user's Stripe Customer.
+A user keeps a single Customer, so one already stored on any of their accounts is reused.
+*/
+func (s *Service) attachStripeBankAccount(ctx context.Context, linkAccount *LinkAccount, bankAccount *StripeBankAccount) error {
+	customerID, err := s.stripeCustomerID(ctx, linkAccount)
+	if err != nil {
+		return err
+	}
+
+	source, err := s.stripe.AttachBankAccount(ctx, customerID, bankAccount.Token)
+	if err != nil {
+		return err
+	}
+
+	bankAccount.StripeCustomerID = customerID
+	bankAccount.StripeBankAccountID = source.ID
+
+	return s.database.UpdateLinkAccountStripe(ctx, UpdateLinkAccountStripeParams{
+		ID:                  linkAccount.ID,
+		StripeCustomerID:    pgtype.Text{String: customerID, Valid: true},
+		StripeBankAccountID: pgtype.Text{String: source.ID, Valid: source.ID != ""},
+	})
+}
+
+/*
+stripeCustomerID returns the user's stored Stripe Customer ID, creating the Customer if none exists yet.
+A new Customer is stored on the account straight away, so a failed attach does not orphan it.
+*/
+func (s *Service) stripeCustomerID(ctx context.Context, linkAccount *LinkAccount) (string, error) {
+	customerID, err := s.database.GetStripeCustomerIDByUserID(ctx, linkAccount.UserID)
+	if err == nil && customerID.Valid {
+		return customerID.String, nil
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+
+	user, err := s.userService.GetUserByID(ctx, linkAccount.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.TrimSpace(user.FirstName.String + " " + user.LastName.String)
+
+	customer, err := s.stripe.CreateCustomer(ctx, user.Email, name, user.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.database.UpdateLinkAccountStripe(ctx, UpdateLinkAccountStripeParams{
+		ID:               linkAccount.ID,
+		StripeCustomerID: pgtype.Text{String: customer.ID, Valid: true},
+	}); err != nil {
+		return "", err
+	}
+
+	return customer.ID, nil
+}
+
+/*
+GetStripeBankAccount returns one of the authenticated user's accounts once it is attached to a Stripe Customer
+*/
+func (s *Service) GetStripeBankAccount(ctx context.Context, ID int64) (*LinkAccount, error) {
+	linkAccount, err := s.authorizeLinkAccount(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !linkAccount.StripeCustomerID.Valid || !linkAccount.StripeBankAccountID.Valid {
+		return nil, ErrStripeBankAccountMissing
+	}
+
+	return linkAccount, nil
+}
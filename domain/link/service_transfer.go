@@ -0,0 +1,394 @@
+package link
+
+import (
+	"context"
+	"driftGo/api/common/utils"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/plaid/plaid-go/v35/plaid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	TransferTypeDebit  = "debit"
+	TransferTypeCredit = "credit"
+
+	transferEventSyncPageSize = 25
+)
+
+var (
+	ErrTransferNotFound          = errors.New("transfer not found")
+	ErrTransferLegalNameRequired = errors.New("user legal name is required for transfers")
+)
+
+/*
+TransferDeclinedError is returned when Plaid does not approve a transfer authorization
+*/
+type TransferDeclinedError struct {
+	Decision    string
+	Code        string
+	Description string
+}
+
+func (e *TransferDeclinedError) Error() string {
+	return fmt.Sprintf("transfer %s: %s", e.Decision, e.Code)
+}
+
+/*
+TransferRequest describes a transfer of a linked account.
+A debit pulls funds from the account, a credit pushes funds to it, and the amount is in minor units.
+*/
+type TransferRequest struct {
+	AccountID   int64
+	Type        string
+	Amount      int64
+	Description string
+	IPAddress   string
+	UserAgent   string
+}
+
+/*
+CreateTransfer moves money to or from one of the authenticated user's accounts through Plaid Transfer.
+Debits must pass the debit guard first. It then runs /transfer/authorization/create, records the decision,
+and only creates the transfer once it is approved.
+*/
+func (s *Service) CreateTransfer(ctx context.Context, request TransferRequest) (*Transfer, error) {
+	linkAccount, err := s.authorizeLinkAccount(ctx, request.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Type == TransferTypeDebit {
+		if _, err := s.checkDebit(ctx, linkAccount, request.Amount, debitCurrency, DebitPurposeTransfer); err != nil {
+			return nil, err
+		}
+	}
+
+	linkItem, err := s.GetLinkItemByID(ctx, linkAccount.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.encryptor.Decrypt(linkItem.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUserByID(ctx, linkAccount.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	legalName := strings.TrimSpace(user.FirstName.String + " " + user.LastName.String)
+	if legalName == "" {
+		return nil, ErrTransferLegalNameRequired
+	}
+
+	amount := formatMinorUnits(request.Amount)
+
+	authorization, err := s.authorizeTransfer(ctx, linkAccount, linkItem.ID, accessToken, request, amount, legalName, user.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if authorization.Decision != string(plaid.TRANSFERAUTHORIZATIONDECISION_APPROVED) {
+		return nil, &TransferDeclinedError{
+			Decision:    authorization.Decision,
+			Code:        authorization.RationaleCode.String,
+			Description: authorization.RationaleDescription.String,
+		}
+	}
+
+	// The row is written before money moves, so a transfer Plaid creates always has a local record
+	transfer, err := s.database.CreateTransfer(ctx, CreateTransferParams{
+		UserID:          linkAccount.UserID,
+		AccountID:       pgtype.Int8{Int64: linkAccount.ID, Valid: true},
+		AuthorizationID: authorization.ID,
+		Type:            request.Type,
+		Amount:          authorization.Amount,
+		Description:     request.Description,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	transferRequest := plaid.NewTransferCreateRequest(accessToken, linkAccount.AccountID, authorization.AuthorizationID, request.Description)
+	// One transfer per authorization, so retries never move money twice
+	transferRequest.SetIdempotencyKey(authorization.AuthorizationID)
+
+	response, _, err := s.client.PlaidApi.TransferCreate(ctx).TransferCreateRequest(*transferRequest).Execute()
+	if err := s.trackItemCall(ctx, linkItem.ID, err); err != nil {
+		// A Plaid error means no transfer was created, anything else may have created one
+		// and is left pending for event sync to link by its authorization
+		if plaidErr, convErr := plaid.ToPlaidError(err); convErr == nil {
+			if updateErr := s.failTransfer(ctx, transfer.ID, plaidErr.ErrorCode, plaidErr.ErrorMessage); updateErr != nil {
+				log.WithError(updateErr).WithField("transfer_id", transfer.ID).Error("Failed to record failed transfer")
+			}
+		}
+		return nil, err
+	}
+
+	plaidTransfer := response.GetTransfer()
+
+	transfer, err = s.database.SetTransferID(ctx, SetTransferIDParams{
+		ID:         transfer.ID,
+		TransferID: pgtype.Text{String: plaidTransfer.GetId(), Valid: true},
+		Status:     TransferStatus(plaidTransfer.GetStatus()),
+	})
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{"transfer_id": transfer.ID, "plaid_transfer_id": plaidTransfer.GetId()}).Error("Failed to record Plaid transfer ID")
+		return nil, err
+	}
+
+	return &transfer, nil
+}
+
+/*
+failTransfer marks a transfer Plaid refused to create as failed
+*/
+func (s *Service) failTransfer(ctx context.Context, ID int64, failureCode, failureDescription string) error {
+	_, err := s.database.UpdateTransferStatusByID(ctx, UpdateTransferStatusByIDParams{
+		ID:                 ID,
+		Status:             TransferStatusFailed,
+		FailureCode:        pgtype.Text{String: failureCode, Valid: failureCode != ""},
+		FailureDescription: pgtype.Text{String: failureDescription, Valid: failureDescription != ""},
+	})
+	return err
+}
+
+/*
+authorizeTransfer runs /transfer/authorization/create and records the decision, approved or not
+*/
+func (s *Service) authorizeTransfer(ctx context.Context, linkAccount *LinkAccount, linkItemID int64, accessToken string, request TransferRequest, amount, legalName, email string) (*TransferAuthorization, error) {
+	user := plaid.NewTransferAuthorizationUserInRequest(legalName)
+	if email != "" {
+		user.SetEmailAddress(email)
+	}
+
+	authorizationRequest := plaid.NewTransferAuthorizationCreateRequest(
+		accessToken,
+		linkAccount.AccountID,
+		plaid.TransferType(request.Type),
+		plaid.TRANSFERNETWORK_ACH,
+		amount,
+		*user,
+	)
+	authorizationRequest.SetAchClass(plaid.ACHCLASS_WEB)
+	authorizationRequest.SetUserPresent(true)
+
+	device := plaid.NewTransferAuthorizationDevice()
+	device.SetIpAddress(request.IPAddress)
+	device.SetUserAgent(request.UserAgent)
+	authorizationRequest.SetDevice(*device)
+
+	response, _, err := s.client.PlaidApi.TransferAuthorizationCreate(ctx).TransferAuthorizationCreateRequest(*authorizationRequest).Execute()
+	if err := s.trackItemCall(ctx, linkItemID, err); err != nil {
+		return nil, err
+	}
+
+	plaidAuthorization := response.GetAuthorization()
+
+	var rationaleCode, rationaleDescription string
+	if rationale, ok := plaidAuthorization.GetDecisionRationaleOk(); ok && rationale != nil {
+		rationaleCode = string(rationale.GetCode())
+		rationaleDescription = rationale.GetDescription()
+	}
+
+	authorization, err := s.database.CreateTransferAuthorization(ctx, CreateTransferAuthorizationParams{
+		UserID:               linkAccount.UserID,
+		AccountID:            pgtype.Int8{Int64: linkAccount.ID, Valid: true},
+		AuthorizationID:      plaidAuthorization.GetId(),
+		Type:                 request.Type,
+		Amount:               minorUnitsToNumeric(request.Amount),
+		Decision:             string(plaidAuthorization.GetDecision()),
+		RationaleCode:        pgtype.Text{String: rationaleCode, Valid: rationaleCode != ""},
+		RationaleDescription: pgtype.Text{String: rationaleDescription, Valid: rationaleDescription != ""},
+		RequestID:            pgtype.Text{String: response.GetRequestId(), Valid: response.GetRequestId() != ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &authorization, nil
+}
+
+/*
+returns one
+*/
+func (s *Service) GetTransfer(ctx context.Context, ID int64) (*Transfer, error) {
+	userID := utils.GetUserID(ctx)
+	if userID == 0 {
+		return nil, errors.New("user ID not found in context")
+	}
+
+	transfer, err := s.database.GetTransferByID(ctx, ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, err
+	}
+
+	if transfer.UserID != userID {
+		return nil, ErrLinkForbidden
+	}
+
+	return &transfer, nil
+}
+
+/*
+returns many
+*/
+func (s *Service) GetTransfersByUser(ctx context.Context, limit, offset int32) ([]Transfer, error) {
+	userID := utils.GetUserID(ctx)
+	if userID == 0 {
+		return nil, errors.New("user ID not found in context")
+	}
+
+	return s.database.GetTransfersByUserID(ctx, GetTransfersByUserIDParams{
+		UserID: userID,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+/*
+SyncTransferEvents pages through /transfer/event/sync from the stored cursor and applies every transfer status change.
+The cursor only moves forward and a transfer ignores events older than the last one applied, so concurrent syncs are safe.
+*/
+func (s *Service) SyncTransferEvents(ctx context.Context) error {
+	afterID, err := s.database.GetTransferEventCursor(ctx)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	for hasMore := true; hasMore; {
+		request := plaid.NewTransferEventSyncRequest(afterID)
+		request.SetCount(transferEventSyncPageSize)
+
+		response, _, err := s.client.PlaidApi.TransferEventSync(ctx).TransferEventSyncRequest(*request).Execute()
+		if err != nil {
+			return err
+		}
+
+		for _, event := range response.GetTransferEvents() {
+			if err := s.applyTransferEvent(ctx, event); err != nil {
+				return err
+			}
+			if event.GetEventId() > afterID {
+				afterID = event.GetEventId()
+			}
+		}
+
+		if err := s.database.UpdateTransferEventCursor(ctx, afterID); err != nil {
+			return err
+		}
+
+		hasMore = response.GetHasMore()
+	}
+
+	return nil
+}
+
+/*
+applyTransferEvent moves a transfer to the status of an event.
+Sweep and refund events describe funds movement on our side and leave the transfer status alone.
+An event for a transfer without a local Plaid ID is matched to its row through the transfer's authorization.
+*/
+func (s *Service) applyTransferEvent(ctx context.Context, event plaid.TransferEvent) error {
+	status, ok := transferStatusForEvent(event.GetEventType())
+	if !ok {
+		return nil
+	}
+
+	var failureCode, achReturnCode, failureDescription string
+	if failure, ok := event.GetFailureReasonOk(); ok && failure != nil {
+		failureCode = failure.GetFailureCode()
+		achReturnCode = failure.GetAchReturnCode()
+		failureDescription = failure.GetDescription()
+	}
+
+	logger := log.WithFields(log.Fields{"transfer_id": event.GetTransferId(), "event_type": event.GetEventType()})
+	logger.Info("Applying transfer event")
+
+	params := UpdateTransferStatusParams{
+		TransferID:         pgtype.Text{String: event.GetTransferId(), Valid: true},
+		Status:             status,
+		FailureCode:        pgtype.Text{String: failureCode, Valid: failureCode != ""},
+		AchReturnCode:      pgtype.Text{String: achReturnCode, Valid: achReturnCode != ""},
+		FailureDescription: pgtype.Text{String: failureDescription, Valid: failureDescription != ""},
+		LastEventID:        event.GetEventId(),
+	}
+
+	updated, err := s.database.UpdateTransferStatus(ctx, params)
+	if err != nil || updated > 0 {
+		return err
+	}
+
+	// Nothing updated, either the event is older than the last one applied or the row has no Plaid ID yet
+	if _, err := s.database.GetTransferByTransferID(ctx, params.TransferID); !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	linked, err := s.linkTransfer(ctx, event.GetTransferId())
+	if err != nil {
+		return err
+	}
+	if !linked {
+		logger.Warn("Transfer event for a transfer with no local record")
+		return nil
+	}
+
+	_, err = s.database.UpdateTransferStatus(ctx, params)
+	return err
+}
+
+/*
+linkTransfer looks a transfer up at Plaid and records its ID on the row created for its authorization.
+It reports false when no row is waiting for the transfer, such as one created outside the application.
+*/
+func (s *Service) linkTransfer(ctx context.Context, transferID string) (bool, error) {
+	request := plaid.NewTransferGetRequest()
+	request.SetTransferId(transferID)
+
+	response, _, err := s.client.PlaidApi.TransferGet(ctx).TransferGetRequest(*request).Execute()
+	if err != nil {
+		return false, err
+	}
+
+	plaidTransfer := response.GetTransfer()
+	linked, err := s.database.LinkTransferByAuthorizationID(ctx, LinkTransferByAuthorizationIDParams{
+		AuthorizationID: plaidTransfer.GetAuthorizationId(),
+		TransferID:      pgtype.Text{String: transferID, Valid: true},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return linked > 0, nil
+}
+
+func transferStatusForEvent(eventType plaid.TransferEventType) (TransferStatus, bool) {
+	switch eventType {
+	case plaid.TRANSFEREVENTTYPE_PENDING:
+		return TransferStatusPending, true
+	case plaid.TRANSFEREVENTTYPE_POSTED:
+		return TransferStatusPosted, true
+	case plaid.TRANSFEREVENTTYPE_SETTLED:
+		return TransferStatusSettled, true
+	case plaid.TRANSFEREVENTTYPE_FUNDS_AVAILABLE:
+		return TransferStatusFundsAvailable, true
+	case plaid.TRANSFEREVENTTYPE_CANCELLED:
+		return TransferStatusCancelled, true
+	case plaid.TRANSFEREVENTTYPE_FAILED:
+		return TransferStatusFailed, true
+	case plaid.TRANSFEREVENTTYPE_RETURNED:
+		return TransferStatusReturned, true
+	default:
+		return "", false
+	}
+}
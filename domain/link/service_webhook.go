@@ -0,0 +1,186 @@
+package link
+
+import (
+	"context"
+	"time"
+
+	"github.com/plaid/plaid-go/v35/plaid"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+Plaid webhook types handled by the link service
+*/
+const (
+	WebhookTypeItem         = "ITEM"
+	WebhookTypeTransactions = "TRANSACTIONS"
+	WebhookTypeAuth         = "AUTH"
+	WebhookTypeHoldings     = "HOLDINGS"
+	WebhookTypeTransfer     = "TRANSFER"
+)
+
+/*
+Plaid webhook codes handled by the link service
+*/
+const (
+	WebhookCodeError                     = "ERROR"
+	WebhookCodePendingExpiration         = "PENDING_EXPIRATION"
+	WebhookCodePendingDisconnect         = "PENDING_DISCONNECT"
+	WebhookCodeUserPermissionRevoked     = "USER_PERMISSION_REVOKED"
+	WebhookCodeUserAccountRevoked        = "USER_ACCOUNT_REVOKED"
+	WebhookCodeLoginRepaired             = "LOGIN_REPAIRED"
+	WebhookCodeNewAccountsAvailable      = "NEW_ACCOUNTS_AVAILABLE"
+	WebhookCodeWebhookUpdateAcknowledged = "WEBHOOK_UPDATE_ACKNOWLEDGED"
+	WebhookCodeSyncUpdatesAvailable      = "SYNC_UPDATES_AVAILABLE"
+	WebhookCodeAutomaticallyVerified     = "AUTOMATICALLY_VERIFIED"
+	WebhookCodeVerificationExpired       = "VERIFICATION_EXPIRED"
+	WebhookCodeDefaultUpdate             = "DEFAULT_UPDATE"
+	WebhookCodeTransferEventsUpdate      = "TRANSFER_EVENTS_UPDATE"
+)
+
+/*
+GetWebhookVerificationKey fetches the public key Plaid used to sign a webhook, identified by its key ID
+*/
+func (s *Service) GetWebhookVerificationKey(ctx context.Context, keyID string) (*plaid.JWKPublicKey, error) {
+	request := plaid.NewWebhookVerificationKeyGetRequest(keyID)
+
+	response, _, err := s.client.PlaidApi.WebhookVerificationKeyGet(ctx).WebhookVerificationKeyGetRequest(*request).Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	key := response.GetKey()
+	return &key, nil
+}
+
+/*
+HandleItemWebhook processes ITEM webhooks, which report the health of a linked item
+*/
+func (s *Service) HandleItemWebhook(ctx context.Context, plaidItemID, webhookCode string, itemError *ItemError, consentExpirationTime *time.Time) error {
+	linkItem, err := s.GetLinkItemByItemID(ctx, plaidItemID)
+	if err != nil {
+		return err
+	}
+
+	logger := log.WithFields(log.Fields{"link_item_id": linkItem.ID, "webhook_code": webhookCode})
+
+	switch webhookCode {
+	case WebhookCodeError:
+		status := LinkItemStatusError
+		if itemError != nil {
+			logger = logger.WithField("error_code", itemError.ErrorCode)
+			status = itemStatusForError(itemError.ErrorCode)
+		}
+		logger.Warn("Plaid reported an item error")
+		if err := s.database.MarkLinkItemUpdateFailed(ctx, linkItem.ID); err != nil {
+			return err
+		}
+		return s.UpdateLinkItemStatus(ctx, linkItem.ID, status, itemError)
+
+	case WebhookCodePendingExpiration:
+		logger.Warn("Plaid item consent expires soon")
+		if err := s.UpdateLinkItemConsentExpiration(ctx, linkItem.ID, consentExpirationTime); err != nil {
+			return err
+		}
+		return s.UpdateLinkItemStatus(ctx, linkItem.ID, LinkItemStatusPendingExpiration, nil)
+
+	case WebhookCodePendingDisconnect:
+		logger.Warn("Plaid item will be disconnected soon")
+		return s.UpdateLinkItemStatus(ctx, linkItem.ID, LinkItemStatusPendingDisconnect, nil)
+
+	case WebhookCodeUserPermissionRevoked, WebhookCodeUserAccountRevoked:
+		logger.Info("User revoked access to the item, removing it")
+		return s.DeleteLinkItemByID(ctx, linkItem.ID)
+
+	case WebhookCodeLoginRepaired:
+		logger.Info("Item login was repaired")
+		return s.UpdateLinkItemStatus(ctx, linkItem.ID, LinkItemStatusHealthy, nil)
+
+	case WebhookCodeNewAccountsAvailable, WebhookCodeWebhookUpdateAcknowledged:
+		logger.Info("Received item webhook")
+
+	default:
+		logger.Info("Received unhandled item webhook code")
+	}
+
+	return nil
+}
+
+/*
+HandleTransactionsWebhook processes TRANSACTIONS webhooks.
+Only SYNC_UPDATES_AVAILABLE is acted on, the other codes belong to the legacy /transactions/get flow.
+*/
+func (s *Service) HandleTransactionsWebhook(ctx context.Context, plaidItemID, webhookCode string) error {
+	linkItem, err := s.GetLinkItemByItemID(ctx, plaidItemID)
+	if err != nil {
+		return err
+	}
+
+	switch webhookCode {
+	case WebhookCodeSyncUpdatesAvailable:
+		return s.SyncTransactions(ctx, linkItem.ID)
+
+	default:
+		log.WithFields(log.Fields{"link_item_id": linkItem.ID, "webhook_code": webhookCode}).Info("Ignoring transactions webhook code")
+	}
+
+	return nil
+}
+
+/*
+HandleAuthWebhook processes AUTH webhooks sent for micro-deposit and database verification updates.
+Once numbers are verified or changed they are fetched again from /auth/get.
+*/
+func (s *Service) HandleAuthWebhook(ctx context.Context, plaidItemID, webhookCode string) error {
+	linkItem, err := s.GetLinkItemByItemID(ctx, plaidItemID)
+	if err != nil {
+		return err
+	}
+
+	logger := log.WithFields(log.Fields{"link_item_id": linkItem.ID, "webhook_code": webhookCode})
+
+	switch webhookCode {
+	case WebhookCodeAutomaticallyVerified, WebhookCodeDefaultUpdate:
+		logger.Info("Auth data is available for the item")
+		_, err := s.syncItemAuth(ctx, linkItem)
+		return err
+
+	case WebhookCodeVerificationExpired:
+		logger.Warn("Auth verification expired for the item")
+
+	default:
+		logger.Info("Received unhandled auth webhook code")
+	}
+
+	return nil
+}
+
+/*
+HandleHoldingsWebhook processes HOLDINGS webhooks sent when investment holdings change
+*/
+func (s *Service) HandleHoldingsWebhook(ctx context.Context, plaidItemID, webhookCode string) error {
+	linkItem, err := s.GetLinkItemByItemID(ctx, plaidItemID)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"link_item_id": linkItem.ID, "webhook_code": webhookCode}).Info("Received holdings webhook")
+
+	return nil
+}
+
+/*
+HandleTransferWebhook processes TRANSFER webhooks.
+They carry no transfer data, new events are read through /transfer/event/sync.
+*/
+func (s *Service) HandleTransferWebhook(ctx context.Context, webhookCode string) error {
+	switch webhookCode {
+	case WebhookCodeTransferEventsUpdate:
+		return s.SyncTransferEvents(ctx)
+
+	default:
+		log.WithField("webhook_code", webhookCode).Info("Received unhandled transfer webhook code")
+	}
+
+	return nil
+}
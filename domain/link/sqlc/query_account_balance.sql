@@ -0,0 +1,26 @@
+-- name: CreateAccountBalanceSnapshot :one
+INSERT INTO account_balance_snapshot (
+    account_id,
+    user_id,
+    current_balance,
+    available_balance,
+    balance_limit,
+    iso_currency_code,
+    unofficial_currency_code,
+    source
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetAccountBalanceSnapshots :many
+SELECT * FROM account_balance_snapshot
+WHERE account_id = sqlc.arg(account_id)
+  AND captured_at >= sqlc.arg(from_time)
+  AND captured_at < sqlc.arg(to_time)
+ORDER BY captured_at ASC;
+
+-- name: GetLatestAccountBalanceSnapshot :one
+SELECT * FROM account_balance_snapshot
+WHERE account_id = $1
+ORDER BY captured_at DESC
+LIMIT 1;
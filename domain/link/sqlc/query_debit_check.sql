@@ -0,0 +1,14 @@
+-- name: CreateDebitCheck :one
+INSERT INTO debit_check (
+    user_id,
+    account_id,
+    purpose,
+    amount,
+    available_balance,
+    current_balance,
+    approved,
+    reason,
+    request_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
@@ -1,15 +1,17 @@
 -- name: CreateLinkAccount :one
 INSERT INTO link_account (
     account_id,
+    account_id_index,
     item_id,
     user_id,
     name,
     official_name,
     mask,
     subtype,
-    type
+    type,
+    persistent_account_id
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 ) RETURNING *;
 
 -- name: GetLinkAccountByID :one
@@ -18,7 +20,7 @@ WHERE id = $1;
 
 -- name: GetLinkAccountByAccountID :one
 SELECT * FROM link_account
-WHERE account_id = $1;
+WHERE account_id_index = $1;
 
 -- name: GetLinkAccountsByItemID :many
 SELECT * FROM link_account
@@ -30,14 +32,78 @@ SELECT * FROM link_account
 WHERE user_id = $1
 ORDER BY created_at DESC;
 
+-- name: GetOpenLinkAccountsByPersistentAccountIDs :many
+SELECT * FROM link_account
+WHERE user_id = $1
+  AND persistent_account_id = ANY(sqlc.arg(persistent_account_ids)::text[])
+  AND closed_at IS NULL
+ORDER BY updated_at DESC;
+
+-- name: MoveLinkAccountToItem :exec
+UPDATE link_account
+SET item_id = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: UpdateLinkAccount :exec
+UPDATE link_account
+SET account_id = $2,
+    account_id_index = $3,
+    name = $4,
+    official_name = $5,
+    mask = $6,
+    subtype = $7,
+    type = $8,
+    persistent_account_id = $9,
+    closed_at = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: CloseLinkAccount :exec
+UPDATE link_account
+SET closed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND closed_at IS NULL;
+
+-- name: UpdateLinkAccountIdentity :exec
+UPDATE link_account
+SET identity_owners = $2,
+    identity_name_score = $3,
+    identity_email_score = $4,
+    identity_match_score = $5,
+    identity_updated_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: UpdateLinkAccountStripe :exec
+UPDATE link_account
+SET stripe_customer_id = $2,
+    stripe_bank_account_id = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: GetStripeCustomerIDByUserID :one
+SELECT stripe_customer_id FROM link_account
+WHERE user_id = $1 AND stripe_customer_id IS NOT NULL
+ORDER BY updated_at DESC
+LIMIT 1;
+
 -- name: DeleteLinkAccount :exec
 DELETE FROM link_account
 WHERE id = $1;
 
 -- name: DeleteLinkAccountByAccountID :exec
 DELETE FROM link_account
-WHERE account_id = $1;
+WHERE account_id_index = $1;
 
 -- name: DeleteLinkAccountsByItemID :exec
 DELETE FROM link_account
-WHERE item_id = $1; 
\ No newline at end of file
+WHERE item_id = $1;
+
+-- name: GetLinkAccountsWithoutIndex :many
+SELECT * FROM link_account
+WHERE account_id_index IS NULL;
+
+-- name: SetLinkAccountIndex :exec
+UPDATE link_account
+SET account_id_index = $2
+WHERE id = $1;
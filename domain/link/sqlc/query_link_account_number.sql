@@ -0,0 +1,26 @@
+-- name: UpsertLinkAccountNumber :one
+INSERT INTO link_account_number (
+    account_id,
+    user_id,
+    network,
+    account_number,
+    routing_number,
+    wire_routing_number,
+    branch_number,
+    is_tokenized
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (account_id) DO UPDATE SET
+    network = EXCLUDED.network,
+    account_number = EXCLUDED.account_number,
+    routing_number = EXCLUDED.routing_number,
+    wire_routing_number = EXCLUDED.wire_routing_number,
+    branch_number = EXCLUDED.branch_number,
+    is_tokenized = EXCLUDED.is_tokenized,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetLinkAccountNumberByAccountID :one
+SELECT * FROM link_account_number
+WHERE account_id = $1;
@@ -2,11 +2,12 @@
 INSERT INTO link_item (
     user_id,
     access_token,
+    access_token_index,
     item_id,
     institution_id,
     institution_name
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6
 ) RETURNING *;
 
 -- name: GetLinkItemByID :one
@@ -26,7 +27,32 @@ ORDER BY created_at DESC;
 SELECT li.access_token
 FROM link_account la
 JOIN link_item li ON la.item_id = li.id
-WHERE la.account_id = $1;
+WHERE la.account_id_index = $1;
+
+-- name: UpdateLinkItemTransactionsCursor :exec
+UPDATE link_item
+SET transactions_cursor = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: UpdateLinkItemStatus :exec
+UPDATE link_item
+SET status = $2, error_code = $3, error_message = $4, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: UpdateLinkItemConsentExpiration :exec
+UPDATE link_item
+SET consent_expiration_time = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: MarkLinkItemUpdateSucceeded :exec
+UPDATE link_item
+SET last_successful_update = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: MarkLinkItemUpdateFailed :exec
+UPDATE link_item
+SET last_failed_update = CURRENT_TIMESTAMP
+WHERE id = $1;
 
 -- name: DeleteLinkItem :exec
 DELETE FROM link_item
@@ -34,4 +60,13 @@ WHERE id = $1;
 
 -- name: DeleteLinkItemByItemID :exec
 DELETE FROM link_item
-WHERE item_id = $1; 
\ No newline at end of file
+WHERE item_id = $1;
+
+-- name: GetLinkItemsWithoutIndex :many
+SELECT * FROM link_item
+WHERE access_token_index IS NULL;
+
+-- name: SetLinkItemIndex :exec
+UPDATE link_item
+SET access_token_index = $2
+WHERE id = $1;
@@ -0,0 +1,53 @@
+-- name: UpsertLinkTransaction :one
+INSERT INTO link_transaction (
+    transaction_id,
+    account_id,
+    item_id,
+    user_id,
+    amount,
+    iso_currency_code,
+    unofficial_currency_code,
+    date,
+    authorized_date,
+    name,
+    merchant_name,
+    payment_channel,
+    category,
+    category_detailed,
+    pending,
+    pending_transaction_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
+)
+ON CONFLICT (transaction_id) DO UPDATE SET
+    account_id = EXCLUDED.account_id,
+    amount = EXCLUDED.amount,
+    iso_currency_code = EXCLUDED.iso_currency_code,
+    unofficial_currency_code = EXCLUDED.unofficial_currency_code,
+    date = EXCLUDED.date,
+    authorized_date = EXCLUDED.authorized_date,
+    name = EXCLUDED.name,
+    merchant_name = EXCLUDED.merchant_name,
+    payment_channel = EXCLUDED.payment_channel,
+    category = EXCLUDED.category,
+    category_detailed = EXCLUDED.category_detailed,
+    pending = EXCLUDED.pending,
+    pending_transaction_id = EXCLUDED.pending_transaction_id,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetLinkTransactionsByUserID :many
+SELECT * FROM link_transaction
+WHERE user_id = $1
+ORDER BY date DESC, id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: DeleteLinkTransactionByTransactionID :exec
+DELETE FROM link_transaction
+WHERE transaction_id = $1;
+
+-- name: MoveLinkTransactionsToItem :exec
+UPDATE link_transaction
+SET item_id = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE account_id = $1;
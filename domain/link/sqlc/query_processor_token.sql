@@ -0,0 +1,30 @@
+-- name: CreateProcessorToken :one
+INSERT INTO processor_token (
+    user_id,
+    item_id,
+    account_id,
+    processor,
+    processor_token,
+    request_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetProcessorTokenByID :one
+SELECT * FROM processor_token
+WHERE id = $1;
+
+-- name: GetProcessorTokensByUserID :many
+SELECT * FROM processor_token
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetActiveProcessorTokenIDsByItemID :many
+SELECT id FROM processor_token
+WHERE item_id = $1 AND revoked_at IS NULL
+ORDER BY id;
+
+-- name: RevokeProcessorTokensByItemID :exec
+UPDATE processor_token
+SET revoked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE item_id = $1 AND revoked_at IS NULL;
@@ -0,0 +1,87 @@
+-- name: CreateTransferAuthorization :one
+INSERT INTO transfer_authorization (
+    user_id,
+    account_id,
+    authorization_id,
+    type,
+    amount,
+    decision,
+    rationale_code,
+    rationale_description,
+    request_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: CreateTransfer :one
+INSERT INTO transfer (
+    user_id,
+    account_id,
+    authorization_id,
+    type,
+    amount,
+    description
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: SetTransferID :one
+UPDATE transfer
+SET transfer_id = $2,
+    status = CASE WHEN last_event_id = 0 THEN $3 ELSE status END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: LinkTransferByAuthorizationID :execrows
+UPDATE transfer
+SET transfer_id = $2,
+    updated_at = CURRENT_TIMESTAMP
+FROM transfer_authorization
+WHERE transfer.authorization_id = transfer_authorization.id
+  AND transfer_authorization.authorization_id = $1
+  AND transfer.transfer_id IS NULL;
+
+-- name: GetTransferByTransferID :one
+SELECT * FROM transfer
+WHERE transfer_id = $1;
+
+-- name: GetTransferByID :one
+SELECT * FROM transfer
+WHERE id = $1;
+
+-- name: GetTransfersByUserID :many
+SELECT * FROM transfer
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: UpdateTransferStatus :execrows
+UPDATE transfer
+SET status = $2,
+    failure_code = $3,
+    ach_return_code = $4,
+    failure_description = $5,
+    last_event_id = $6,
+    updated_at = CURRENT_TIMESTAMP
+WHERE transfer_id = $1 AND last_event_id < $6;
+
+-- name: UpdateTransferStatusByID :one
+UPDATE transfer
+SET status = $2,
+    failure_code = $3,
+    failure_description = $4,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: GetTransferEventCursor :one
+SELECT last_event_id FROM transfer_event_cursor
+WHERE id = 1;
+
+-- name: UpdateTransferEventCursor :exec
+INSERT INTO transfer_event_cursor (id, last_event_id)
+VALUES (1, $1)
+ON CONFLICT (id) DO UPDATE
+SET last_event_id = GREATEST(transfer_event_cursor.last_event_id, EXCLUDED.last_event_id),
+    updated_at = CURRENT_TIMESTAMP;
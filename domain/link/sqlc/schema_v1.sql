@@ -1,22 +1,33 @@
 -- Link domain schema
+CREATE TYPE link_item_status AS ENUM ('healthy', 'login_required', 'pending_expiration', 'pending_disconnect', 'error');
+
 CREATE TABLE IF NOT EXISTS link_item (
     id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
     user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     access_token TEXT NOT NULL, -- encrypt at rest
+    access_token_index TEXT, -- blind index of access_token
     item_id TEXT NOT NULL,
     institution_id TEXT,
     institution_name TEXT,
+    transactions_cursor TEXT,
+    status link_item_status NOT NULL DEFAULT 'healthy',
+    error_code TEXT,
+    error_message TEXT,
+    consent_expiration_time TIMESTAMPTZ,
+    last_successful_update TIMESTAMPTZ,
+    last_failed_update TIMESTAMPTZ,
     created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
 );
 
 CREATE INDEX IF NOT EXISTS idx_link_item_user_id ON link_item(user_id);
 CREATE INDEX IF NOT EXISTS idx_link_item_item_id ON link_item(item_id);
-CREATE INDEX IF NOT EXISTS idx_link_item_access_token ON link_item(access_token);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_link_item_access_token_index ON link_item(access_token_index);
 
 CREATE TABLE IF NOT EXISTS link_account (
     id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
     account_id TEXT NOT NULL UNIQUE, -- encrypt at rest
+    account_id_index TEXT, -- blind index of account_id
     item_id BIGINT NOT NULL REFERENCES link_item(id) ON DELETE CASCADE,
     user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     name TEXT,
@@ -24,9 +35,152 @@ CREATE TABLE IF NOT EXISTS link_account (
     mask TEXT,
     subtype TEXT,
     type TEXT,
-    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+    persistent_account_id TEXT, -- stable across re-links at tokenized institutions
+    closed_at TIMESTAMPTZ, -- set once Plaid stops returning the account
+    identity_owners TEXT, -- encrypt at rest
+    identity_name_score INTEGER,
+    identity_email_score INTEGER,
+    identity_match_score INTEGER,
+    identity_updated_at TIMESTAMPTZ,
+    stripe_customer_id TEXT,
+    stripe_bank_account_id TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
 );
 
-CREATE INDEX IF NOT EXISTS idx_link_account_account_id ON link_account(account_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_link_account_account_id_index ON link_account(account_id_index);
 CREATE INDEX IF NOT EXISTS idx_link_account_item_id ON link_account(item_id);
-CREATE INDEX IF NOT EXISTS idx_link_account_user_id ON link_account(user_id); 
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_link_account_user_id ON link_account(user_id); 
+
+CREATE TABLE IF NOT EXISTS link_transaction (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    transaction_id TEXT NOT NULL UNIQUE,
+    account_id BIGINT NOT NULL REFERENCES link_account(id) ON DELETE CASCADE,
+    item_id BIGINT NOT NULL REFERENCES link_item(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount NUMERIC(19, 4) NOT NULL,
+    iso_currency_code TEXT,
+    unofficial_currency_code TEXT,
+    date DATE NOT NULL,
+    authorized_date DATE,
+    name TEXT,
+    merchant_name TEXT,
+    payment_channel TEXT,
+    category TEXT,
+    category_detailed TEXT,
+    pending BOOLEAN NOT NULL DEFAULT FALSE,
+    pending_transaction_id TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_transaction_account_id ON link_transaction(account_id);
+CREATE INDEX IF NOT EXISTS idx_link_transaction_item_id ON link_transaction(item_id);
+CREATE INDEX IF NOT EXISTS idx_link_transaction_user_id_date ON link_transaction(user_id, date DESC);
+
+CREATE TABLE IF NOT EXISTS account_balance_snapshot (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    account_id BIGINT NOT NULL REFERENCES link_account(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    current_balance NUMERIC(19, 4),
+    available_balance NUMERIC(19, 4),
+    balance_limit NUMERIC(19, 4),
+    iso_currency_code TEXT,
+    unofficial_currency_code TEXT,
+    source TEXT NOT NULL, -- realtime or cached
+    captured_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_balance_snapshot_account_id_captured_at ON account_balance_snapshot(account_id, captured_at DESC);
+
+CREATE TABLE IF NOT EXISTS link_account_number (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    account_id BIGINT NOT NULL UNIQUE REFERENCES link_account(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    network TEXT NOT NULL, -- ach or eft
+    account_number TEXT NOT NULL, -- encrypt at rest
+    routing_number TEXT NOT NULL, -- encrypt at rest, ACH routing or EFT institution number
+    wire_routing_number TEXT, -- encrypt at rest
+    branch_number TEXT, -- encrypt at rest, EFT only
+    is_tokenized BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS processor_token (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    item_id BIGINT REFERENCES link_item(id) ON DELETE SET NULL,
+    account_id BIGINT REFERENCES link_account(id) ON DELETE SET NULL,
+    processor TEXT NOT NULL,
+    processor_token TEXT NOT NULL, -- encrypt at rest
+    request_id TEXT,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_processor_token_user_id ON processor_token(user_id);
+CREATE INDEX IF NOT EXISTS idx_processor_token_item_id ON processor_token(item_id);
+
+CREATE TYPE transfer_status AS ENUM ('pending', 'posted', 'settled', 'funds_available', 'cancelled', 'failed', 'returned');
+
+CREATE TABLE IF NOT EXISTS transfer_authorization (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    account_id BIGINT REFERENCES link_account(id) ON DELETE SET NULL,
+    authorization_id TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL,
+    amount NUMERIC(28, 2) NOT NULL,
+    decision TEXT NOT NULL,
+    rationale_code TEXT,
+    rationale_description TEXT,
+    request_id TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfer_authorization_user_id ON transfer_authorization(user_id);
+
+CREATE TABLE IF NOT EXISTS transfer (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    account_id BIGINT REFERENCES link_account(id) ON DELETE SET NULL,
+    authorization_id BIGINT NOT NULL UNIQUE REFERENCES transfer_authorization(id),
+    transfer_id TEXT UNIQUE,
+    type TEXT NOT NULL,
+    amount NUMERIC(28, 2) NOT NULL,
+    description TEXT NOT NULL,
+    status transfer_status NOT NULL DEFAULT 'pending',
+    failure_code TEXT,
+    ach_return_code TEXT,
+    failure_description TEXT,
+    last_event_id INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfer_user_id ON transfer(user_id);
+
+-- Last /transfer/event/sync event processed, a single row
+CREATE TABLE IF NOT EXISTS transfer_event_cursor (
+    id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    last_event_id INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Balance checks run before money is pulled from a linked account
+CREATE TABLE IF NOT EXISTS debit_check (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    account_id BIGINT REFERENCES link_account(id) ON DELETE SET NULL,
+    purpose TEXT NOT NULL,
+    amount NUMERIC(28, 2),
+    available_balance NUMERIC(28, 2),
+    current_balance NUMERIC(28, 2),
+    approved BOOLEAN NOT NULL,
+    reason TEXT,
+    request_id TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_debit_check_account_id ON debit_check(account_id);
@@ -0,0 +1,27 @@
+package payment
+
+import (
+	"context"
+	"driftGo/domain/link"
+)
+
+/*
+AccountProvider resolves the Stripe bank account of a linked account the authenticated user owns
+and runs the debit guard on it
+*/
+type AccountProvider interface {
+	GetStripeBankAccount(ctx context.Context, ID int64) (*link.LinkAccount, error)
+	CheckDebit(ctx context.Context, accountID int64, amount int64, currency, purpose string) (*link.DebitCheck, error)
+}
+
+/*
+ACHPaymentRequest describes an ACH debit of a linked account, the amount is in minor units
+*/
+type ACHPaymentRequest struct {
+	AccountID   int64
+	Amount      int64
+	Currency    string
+	Description string
+	IPAddress   string
+	UserAgent   string
+}
@@ -0,0 +1,184 @@
+package payment
+
+import (
+	"context"
+	"driftGo/api/common/utils"
+	"driftGo/domain/link"
+	"driftGo/pkg/stripe"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	ErrPaymentNotFound     = errors.New("payment not found")
+	ErrPaymentForbidden    = errors.New("payment does not belong to user")
+	ErrStripeNotConfigured = errors.New("stripe is not configured")
+)
+
+/*
+Service handles ACH payments of linked accounts through Stripe
+*/
+type Service struct {
+	database Querier
+	accounts AccountProvider
+	stripe   *stripe.Client
+}
+
+/*
+NewService creates a new payment service, stripeClient may be nil when Stripe is not configured
+*/
+func NewService(db *pgxpool.Pool, accounts AccountProvider, stripeClient *stripe.Client) *Service {
+	return &Service{
+		database: New(db),
+		accounts: accounts,
+		stripe:   stripeClient,
+	}
+}
+
+/*
+CreateACHPayment debits one of the authenticated user's Stripe attached accounts once it passes the debit guard.
+The payment is recorded before Stripe is called and its ID is used as the idempotency key and intent metadata,
+so a failed call leaves a failed or pending record instead of an untracked charge.
+*/
+func (s *Service) CreateACHPayment(ctx context.Context, request ACHPaymentRequest) (*Payment, error) {
+	if s.stripe == nil {
+		return nil, ErrStripeNotConfigured
+	}
+
+	linkAccount, err := s.accounts.GetStripeBankAccount(ctx, request.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := strings.ToLower(request.Currency)
+
+	if _, err := s.accounts.CheckDebit(ctx, linkAccount.ID, request.Amount, currency, link.DebitPurposePayment); err != nil {
+		return nil, err
+	}
+
+	payment, err := s.database.CreatePayment(ctx, CreatePaymentParams{
+		UserID:      linkAccount.UserID,
+		AccountID:   pgtype.Int8{Int64: linkAccount.ID, Valid: true},
+		Amount:      request.Amount,
+		Currency:    currency,
+		Description: pgtype.Text{String: request.Description, Valid: request.Description != ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	paymentIntent, err := s.stripe.CreateACHDebit(ctx, stripe.ACHDebitParams{
+		Amount:        request.Amount,
+		Currency:      currency,
+		CustomerID:    linkAccount.StripeCustomerID.String,
+		BankAccountID: linkAccount.StripeBankAccountID.String,
+		Description:   request.Description,
+		IPAddress:     request.IPAddress,
+		UserAgent:     request.UserAgent,
+		Metadata:      map[string]string{"payment_id": fmt.Sprint(payment.ID)},
+	}, fmt.Sprintf("payment-%d", payment.ID))
+	if err != nil {
+		// Only a client error from Stripe means no charge was made. A timeout or server error may have
+		// created the PaymentIntent anyway, so the payment stays pending until its webhook links it.
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.StatusCode < 500 {
+			if _, updateErr := s.updateStatus(ctx, payment.ID, PaymentStatusFailed, stripeErr.Code, err.Error()); updateErr != nil {
+				log.WithError(updateErr).WithField("payment_id", payment.ID).Error("Failed to record failed payment")
+			}
+		}
+		return nil, err
+	}
+
+	updated, err := s.database.SetPaymentIntent(ctx, SetPaymentIntentParams{
+		ID:                    payment.ID,
+		StripePaymentIntentID: pgtype.Text{String: paymentIntent.ID, Valid: true},
+		Status:                statusForPaymentIntent(paymentIntent.Status),
+	})
+	if err != nil {
+		// The webhook finds the payment through the intent metadata and records the ID then
+		log.WithError(err).WithFields(log.Fields{"payment_id": payment.ID, "stripe_payment_intent_id": paymentIntent.ID}).Error("Failed to record payment intent")
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+/*
+returns one
+*/
+func (s *Service) GetPayment(ctx context.Context, ID int64) (*Payment, error) {
+	userID := utils.GetUserID(ctx)
+	if userID == 0 {
+		return nil, errors.New("user ID not found in context")
+	}
+
+	payment, err := s.database.GetPaymentByID(ctx, ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	if payment.UserID != userID {
+		return nil, ErrPaymentForbidden
+	}
+
+	return &payment, nil
+}
+
+/*
+returns many
+*/
+func (s *Service) GetPaymentsByUser(ctx context.Context, limit, offset int32) ([]Payment, error) {
+	userID := utils.GetUserID(ctx)
+	if userID == 0 {
+		return nil, errors.New("user ID not found in context")
+	}
+
+	return s.database.GetPaymentsByUserID(ctx, GetPaymentsByUserIDParams{
+		UserID: userID,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+/*
+returns one
+*/
+func (s *Service) updateStatus(ctx context.Context, ID int64, status PaymentStatus, failureCode, failureMessage string) (*Payment, error) {
+	payment, err := s.database.UpdatePaymentStatus(ctx, UpdatePaymentStatusParams{
+		ID:             ID,
+		Status:         status,
+		FailureCode:    pgtype.Text{String: failureCode, Valid: failureCode != ""},
+		FailureMessage: pgtype.Text{String: failureMessage, Valid: failureMessage != ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+/*
+statusForPaymentIntent maps a PaymentIntent status onto a payment status.
+Intents still waiting on an action are kept pending, a later webhook moves them on.
+*/
+func statusForPaymentIntent(status string) PaymentStatus {
+	switch status {
+	case stripe.PaymentIntentStatusProcessing:
+		return PaymentStatusProcessing
+	case stripe.PaymentIntentStatusSucceeded:
+		return PaymentStatusSucceeded
+	case stripe.PaymentIntentStatusCanceled:
+		return PaymentStatusCanceled
+	default:
+		return PaymentStatusPending
+	}
+}
@@ -0,0 +1,131 @@
+package payment
+
+import (
+	"context"
+	"driftGo/pkg/stripe"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+HandleStripeEvent applies a verified Stripe webhook event to the payment it refers to.
+Stripe does not guarantee delivery order, so a status never moves back from a final one;
+a dispute is the only change accepted after a payment succeeded.
+*/
+func (s *Service) HandleStripeEvent(ctx context.Context, event stripe.Event) error {
+	logger := log.WithFields(log.Fields{"stripe_event_id": event.ID, "stripe_event_type": event.Type})
+
+	var paymentIntentID, metadataPaymentID string
+	var status PaymentStatus
+	var failureCode, failureMessage string
+
+	switch event.Type {
+	case stripe.EventPaymentIntentProcessing, stripe.EventPaymentIntentSucceeded,
+		stripe.EventPaymentIntentPaymentFailed, stripe.EventPaymentIntentCanceled:
+		var paymentIntent stripe.PaymentIntent
+		if err := json.Unmarshal(event.Data.Object, &paymentIntent); err != nil {
+			return err
+		}
+		paymentIntentID = paymentIntent.ID
+		metadataPaymentID = paymentIntent.Metadata["payment_id"]
+
+		switch event.Type {
+		case stripe.EventPaymentIntentProcessing:
+			status = PaymentStatusProcessing
+		case stripe.EventPaymentIntentSucceeded:
+			status = PaymentStatusSucceeded
+		case stripe.EventPaymentIntentCanceled:
+			status = PaymentStatusCanceled
+		default:
+			status = PaymentStatusFailed
+			if paymentIntent.LastPaymentError != nil {
+				failureCode = paymentIntent.LastPaymentError.Code
+				failureMessage = paymentIntent.LastPaymentError.Message
+			}
+		}
+
+	case stripe.EventChargeDisputeCreated:
+		var dispute stripe.Dispute
+		if err := json.Unmarshal(event.Data.Object, &dispute); err != nil {
+			return err
+		}
+		paymentIntentID = dispute.PaymentIntent
+		status = PaymentStatusDisputed
+		failureCode = dispute.Reason
+
+	default:
+		logger.Info("Ignoring Stripe event type")
+		return nil
+	}
+
+	payment, err := s.database.GetPaymentByPaymentIntentID(ctx, pgtype.Text{String: paymentIntentID, Valid: paymentIntentID != ""})
+	if errors.Is(err, pgx.ErrNoRows) && metadataPaymentID != "" {
+		payment, err = s.linkPaymentIntent(ctx, metadataPaymentID, paymentIntentID)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Warn("Stripe event for unknown payment intent")
+			return nil
+		}
+		return err
+	}
+
+	if !canTransition(payment.Status, status) {
+		logger.WithFields(log.Fields{"payment_id": payment.ID, "status": payment.Status}).Info("Ignoring out of order Stripe event")
+		return nil
+	}
+
+	_, err = s.updateStatus(ctx, payment.ID, status, failureCode, failureMessage)
+	return err
+}
+
+/*
+linkPaymentIntent finds a payment through the payment_id metadata of its intent and records the intent ID.
+This covers a create call whose response was lost, where the payment was left without its intent.
+A payment already linked to a different intent is treated as unknown.
+*/
+func (s *Service) linkPaymentIntent(ctx context.Context, metadataPaymentID, paymentIntentID string) (Payment, error) {
+	ID, err := strconv.ParseInt(metadataPaymentID, 10, 64)
+	if err != nil {
+		return Payment{}, pgx.ErrNoRows
+	}
+
+	payment, err := s.database.GetPaymentByID(ctx, ID)
+	if err != nil {
+		return Payment{}, err
+	}
+
+	if payment.StripePaymentIntentID.Valid {
+		if payment.StripePaymentIntentID.String != paymentIntentID {
+			return Payment{}, pgx.ErrNoRows
+		}
+		return payment, nil
+	}
+
+	return s.database.SetPaymentIntent(ctx, SetPaymentIntentParams{
+		ID:                    payment.ID,
+		StripePaymentIntentID: pgtype.Text{String: paymentIntentID, Valid: true},
+		Status:                payment.Status,
+	})
+}
+
+/*
+canTransition reports whether a payment may move from one status to another
+*/
+func canTransition(from, to PaymentStatus) bool {
+	switch from {
+	case PaymentStatusPending:
+		return true
+	case PaymentStatusProcessing:
+		return to != PaymentStatusPending
+	case PaymentStatusSucceeded:
+		return to == PaymentStatusDisputed
+	default:
+		return false
+	}
+}
@@ -0,0 +1,41 @@
+-- name: CreatePayment :one
+INSERT INTO payment (
+    user_id,
+    account_id,
+    amount,
+    currency,
+    description
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetPaymentByID :one
+SELECT * FROM payment
+WHERE id = $1;
+
+-- name: GetPaymentByPaymentIntentID :one
+SELECT * FROM payment
+WHERE stripe_payment_intent_id = $1;
+
+-- name: GetPaymentsByUserID :many
+SELECT * FROM payment
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: SetPaymentIntent :one
+UPDATE payment
+SET stripe_payment_intent_id = $2,
+    status = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdatePaymentStatus :one
+UPDATE payment
+SET status = $2,
+    failure_code = $3,
+    failure_message = $4,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,19 @@
+-- Payment domain schema
+CREATE TYPE payment_status AS ENUM ('pending', 'processing', 'succeeded', 'failed', 'canceled', 'disputed');
+
+CREATE TABLE IF NOT EXISTS payment (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    account_id BIGINT REFERENCES link_account(id) ON DELETE SET NULL,
+    amount BIGINT NOT NULL, -- minor units
+    currency TEXT NOT NULL,
+    description TEXT,
+    status payment_status NOT NULL DEFAULT 'pending',
+    stripe_payment_intent_id TEXT UNIQUE,
+    failure_code TEXT,
+    failure_message TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_user_id ON payment(user_id);
@@ -3,9 +3,11 @@ package encryption
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -16,16 +18,20 @@ var (
 	ErrInvalidCiphertext = errors.New("invalid ciphertext")
 )
 
+const blindIndexContext = "driftGo/blind-index"
+
 /*
 Encryptor handles encryption and decryption of sensitive data
 */
 type Encryptor struct {
-	key []byte
+	key      []byte
+	indexKey []byte
 }
 
 /*
 NewEncryptor creates a new encryptor with the provided key string
 The key string will be hashed to produce a 32-byte key for AES-256
+A separate HMAC key for blind indexes is derived from the same key string
 */
 func NewEncryptor(keyString string) (*Encryptor, error) {
 	if len(keyString) < 32 {
@@ -33,7 +39,26 @@ func NewEncryptor(keyString string) (*Encryptor, error) {
 	}
 
 	hash := sha256.Sum256([]byte(keyString))
-	return &Encryptor{key: hash[:]}, nil
+
+	mac := hmac.New(sha256.New, []byte(keyString))
+	mac.Write([]byte(blindIndexContext))
+
+	return &Encryptor{key: hash[:], indexKey: mac.Sum(nil)}, nil
+}
+
+/*
+BlindIndex returns a deterministic keyed HMAC-SHA256 of the plaintext, hex encoded.
+Encrypt uses a random nonce, so lookups on encrypted columns must go through this index instead.
+*/
+func (e *Encryptor) BlindIndex(plaintext string) string {
+	if plaintext == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, e.indexKey)
+	mac.Write([]byte(plaintext))
+
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 func (e *Encryptor) Encrypt(plaintext string) (string, error) {
@@ -63,3 +63,32 @@ func TestEmptyStringHandling(t *testing.T) {
 		t.Fatal("Empty string should decrypt to empty string")
 	}
 }
+
+func TestBlindIndex(t *testing.T) {
+	encryptor, err := NewEncryptor("12345678901234567890123456789012")
+	if err != nil {
+		t.Fatalf("Failed to create encryptor: %v", err)
+	}
+
+	first := encryptor.BlindIndex("account-id-12345")
+	second := encryptor.BlindIndex("account-id-12345")
+	if first != second {
+		t.Fatal("Blind index should be deterministic for the same input")
+	}
+
+	if first == encryptor.BlindIndex("account-id-67890") {
+		t.Fatal("Blind index should differ for different inputs")
+	}
+
+	otherEncryptor, err := NewEncryptor("abcdefghijklmnopqrstuvwxyz123456")
+	if err != nil {
+		t.Fatalf("Failed to create encryptor: %v", err)
+	}
+	if first == otherEncryptor.BlindIndex("account-id-12345") {
+		t.Fatal("Blind index should differ for different keys")
+	}
+
+	if encryptor.BlindIndex("") != "" {
+		t.Fatal("Empty string should index to empty string")
+	}
+}
@@ -0,0 +1,146 @@
+package stripe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://api.stripe.com"
+
+/*
+Client is a minimal client for the parts of the Stripe REST API the application uses.
+The base URL is configurable so it can be pointed at a local stand-in.
+*/
+type Client struct {
+	secretKey  string
+	baseURL    string
+	httpClient *http.Client
+}
+
+/*
+Error is the error object Stripe returns with non-2xx responses
+*/
+type Error struct {
+	StatusCode int    `json:"-"`
+	Type       string `json:"type"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Param      string `json:"param"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("stripe: %s (%d %s)", e.Message, e.StatusCode, e.Code)
+}
+
+/*
+Customer is the subset of a Stripe Customer the application reads
+*/
+type Customer struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+/*
+BankAccount is the subset of a Stripe bank account source the application reads
+*/
+type BankAccount struct {
+	ID       string `json:"id"`
+	Customer string `json:"customer"`
+	BankName string `json:"bank_name"`
+	Last4    string `json:"last4"`
+	Status   string `json:"status"`
+}
+
+/*
+NewClient creates a Stripe client, defaulting to the public API when baseURL is empty
+*/
+func NewClient(secretKey, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		secretKey:  secretKey,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+/*
+CreateCustomer creates a Stripe Customer, tagging it with our user ID in its metadata.
+The user ID also keys the request, so concurrent or retried calls for one user share a Customer.
+*/
+func (c *Client) CreateCustomer(ctx context.Context, email, name string, userID int64) (*Customer, error) {
+	form := url.Values{}
+	form.Set("email", email)
+	if name != "" {
+		form.Set("name", name)
+	}
+	form.Set("metadata[user_id]", fmt.Sprint(userID))
+
+	var customer Customer
+	if err := c.post(ctx, "/v1/customers", form, fmt.Sprintf("customer-user-%d", userID), &customer); err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+/*
+AttachBankAccount attaches a bank account token, such as one created by Plaid, to a Customer as a source
+*/
+func (c *Client) AttachBankAccount(ctx context.Context, customerID, bankAccountToken string) (*BankAccount, error) {
+	form := url.Values{}
+	form.Set("source", bankAccountToken)
+
+	var bankAccount BankAccount
+	if err := c.post(ctx, "/v1/customers/"+url.PathEscape(customerID)+"/sources", form, "", &bankAccount); err != nil {
+		return nil, err
+	}
+	return &bankAccount, nil
+}
+
+/*
+post sends a form encoded request and decodes the JSON response into out.
+Retries of the same operation must reuse the idempotency key so Stripe does not repeat it.
+*/
+func (c *Client) post(ctx context.Context, path string, form url.Values, idempotencyKey string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.secretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp struct {
+			Error Error `json:"error"`
+		}
+		if err := json.Unmarshal(body, &errResp); err != nil {
+			return fmt.Errorf("stripe: unexpected status %d", resp.StatusCode)
+		}
+		errResp.Error.StatusCode = resp.StatusCode
+		return &errResp.Error
+	}
+
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,49 @@
+package stripe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAttachBankAccount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/customers/cus_123/sources" {
+			t.Fatalf("Unexpected path %s", r.URL.Path)
+		}
+		if user, _, _ := r.BasicAuth(); user != "sk_test" {
+			t.Fatalf("Expected secret key as basic auth user, got %q", user)
+		}
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("source") != "btok_123" {
+			t.Fatalf("Expected source form value, got %v", r.PostForm)
+		}
+		w.Write([]byte(`{"id":"ba_123","customer":"cus_123","last4":"6789"}`))
+	}))
+	defer server.Close()
+
+	bankAccount, err := NewClient("sk_test", server.URL).AttachBankAccount(context.Background(), "cus_123", "btok_123")
+	if err != nil {
+		t.Fatalf("Expected bank account, got %v", err)
+	}
+	if bankAccount.ID != "ba_123" || bankAccount.Last4 != "6789" {
+		t.Fatalf("Unexpected bank account %+v", bankAccount)
+	}
+}
+
+func TestPostReturnsStripeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		w.Write([]byte(`{"error":{"type":"invalid_request_error","code":"resource_missing","message":"No such token"}}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient("sk_test", server.URL).AttachBankAccount(context.Background(), "cus_123", "btok_missing")
+	stripeErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Expected *Error, got %v", err)
+	}
+	if stripeErr.StatusCode != http.StatusPaymentRequired || stripeErr.Code != "resource_missing" {
+		t.Fatalf("Unexpected error %+v", stripeErr)
+	}
+}
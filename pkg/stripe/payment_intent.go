@@ -0,0 +1,81 @@
+package stripe
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+/*
+PaymentIntent statuses the application acts on
+*/
+const (
+	PaymentIntentStatusProcessing = "processing"
+	PaymentIntentStatusSucceeded  = "succeeded"
+	PaymentIntentStatusCanceled   = "canceled"
+)
+
+/*
+PaymentIntent is the subset of a Stripe PaymentIntent the application reads
+*/
+type PaymentIntent struct {
+	ID               string            `json:"id"`
+	Amount           int64             `json:"amount"`
+	Currency         string            `json:"currency"`
+	Customer         string            `json:"customer"`
+	Status           string            `json:"status"`
+	LastPaymentError *PaymentError     `json:"last_payment_error"`
+	Metadata         map[string]string `json:"metadata"`
+}
+
+/*
+PaymentError is the error Stripe attaches to a PaymentIntent whose last attempt failed
+*/
+type PaymentError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+/*
+ACHDebitParams describe an ACH debit of a customer's bank account.
+IPAddress and UserAgent record the customer's acceptance of the debit mandate.
+*/
+type ACHDebitParams struct {
+	Amount        int64
+	Currency      string
+	CustomerID    string
+	BankAccountID string
+	Description   string
+	IPAddress     string
+	UserAgent     string
+	Metadata      map[string]string
+}
+
+/*
+CreateACHDebit creates and confirms a PaymentIntent debiting a bank account attached to a Customer.
+ACH debits settle asynchronously, so the intent is normally returned in processing and finalized through webhooks.
+*/
+func (c *Client) CreateACHDebit(ctx context.Context, params ACHDebitParams, idempotencyKey string) (*PaymentIntent, error) {
+	form := url.Values{}
+	form.Set("amount", fmt.Sprint(params.Amount))
+	form.Set("currency", params.Currency)
+	form.Set("customer", params.CustomerID)
+	form.Set("payment_method", params.BankAccountID)
+	form.Set("payment_method_types[]", "us_bank_account")
+	form.Set("confirm", "true")
+	form.Set("mandate_data[customer_acceptance][type]", "online")
+	form.Set("mandate_data[customer_acceptance][online][ip_address]", params.IPAddress)
+	form.Set("mandate_data[customer_acceptance][online][user_agent]", params.UserAgent)
+	if params.Description != "" {
+		form.Set("description", params.Description)
+	}
+	for key, value := range params.Metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+
+	var paymentIntent PaymentIntent
+	if err := c.post(ctx, "/v1/payment_intents", form, idempotencyKey, &paymentIntent); err != nil {
+		return nil, err
+	}
+	return &paymentIntent, nil
+}
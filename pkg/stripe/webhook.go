@@ -0,0 +1,110 @@
+package stripe
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignatureHeader = "Stripe-Signature"
+
+	// Maximum age of a signed webhook, matching Stripe's own libraries
+	signatureTolerance = 5 * time.Minute
+)
+
+/*
+Webhook event types the application handles
+*/
+const (
+	EventPaymentIntentProcessing    = "payment_intent.processing"
+	EventPaymentIntentSucceeded     = "payment_intent.succeeded"
+	EventPaymentIntentPaymentFailed = "payment_intent.payment_failed"
+	EventPaymentIntentCanceled      = "payment_intent.canceled"
+	EventChargeDisputeCreated       = "charge.dispute.created"
+)
+
+var (
+	ErrMissingSignature = errors.New("missing Stripe-Signature header")
+	ErrInvalidSignature = errors.New("invalid Stripe webhook signature")
+	ErrStaleSignature   = errors.New("stripe webhook timestamp outside tolerance")
+)
+
+/*
+Event is a Stripe webhook event, the object is decoded according to the event type
+*/
+type Event struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+/*
+Dispute is the subset of a Stripe Dispute the application reads
+*/
+type Dispute struct {
+	ID            string `json:"id"`
+	PaymentIntent string `json:"payment_intent"`
+	Reason        string `json:"reason"`
+	Status        string `json:"status"`
+}
+
+/*
+VerifySignature checks the Stripe-Signature header of a webhook against the endpoint secret.
+The header carries a timestamp and one or more v1 HMAC-SHA256 signatures of "timestamp.body".
+*/
+func VerifySignature(secret, header string, body []byte, now time.Time) error {
+	if header == "" {
+		return ErrMissingSignature
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	valid := false
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	if age := now.Sub(time.Unix(seconds, 0)); age > signatureTolerance || age < -signatureTolerance {
+		return ErrStaleSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package stripe
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func signHeader(secret string, body []byte, timestamp time.Time) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d.", timestamp.Unix())))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp.Unix(), hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"id":"evt_123","type":"payment_intent.succeeded"}`)
+	now := time.Now()
+
+	if err := VerifySignature("whsec_test", signHeader("whsec_test", body, now), body, now); err != nil {
+		t.Fatalf("Expected valid signature, got %v", err)
+	}
+
+	if err := VerifySignature("whsec_test", signHeader("whsec_other", body, now), body, now); err != ErrInvalidSignature {
+		t.Fatalf("Expected ErrInvalidSignature, got %v", err)
+	}
+
+	if err := VerifySignature("whsec_test", signHeader("whsec_test", body, now.Add(-10*time.Minute)), body, now); err != ErrStaleSignature {
+		t.Fatalf("Expected ErrStaleSignature, got %v", err)
+	}
+
+	if err := VerifySignature("whsec_test", "", body, now); err != ErrMissingSignature {
+		t.Fatalf("Expected ErrMissingSignature, got %v", err)
+	}
+}